@@ -3,7 +3,6 @@ package buffer
 import (
 	"log"
 
-	"github.com/lintang-b-s/rtreed/lib"
 	"github.com/lintang-b-s/rtreed/lib/concurrent"
 	"github.com/lintang-b-s/rtreed/lib/disk"
 	"github.com/lintang-b-s/rtreed/lib/meta"
@@ -150,9 +149,9 @@ func (buf *Buffer) getIsDirty() bool {
 	return buf.isDirty
 }
 
-// ResetMemory. reset buffer contents jadi byte array dengan capacity MAX_PAGE_SIZE.
+// ResetMemory. reset buffer contents jadi byte array dengan capacity blockSize dari disk manager.
 func (buf *Buffer) ResetMemory() {
-	buf.contents = disk.NewPage(lib.MAX_PAGE_SIZE)
+	buf.contents = disk.NewPage(buf.diskManager.BlockSize())
 }
 
 func (buf *Buffer) getContentsSize() *disk.Page {
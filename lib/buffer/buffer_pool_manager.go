@@ -1,6 +1,7 @@
 package buffer
 
 import (
+	"errors"
 	"fmt"
 
 	"github.com/lintang-b-s/rtreed/lib"
@@ -11,6 +12,11 @@ import (
 
 // https://15445.courses.cs.cmu.edu/spring2023/slides/06-bufferpool.pdf
 
+var (
+	ErrNoAvailableFrame = errors.New("no available frame")
+	ErrAllPagesPinned   = errors.New("all pages are pinned")
+)
+
 type BufferPoolManager struct {
 	bufferPool   []*Buffer // pages dari disk yang sementara disimpan di buffer.
 	numAvailable int       // jumlah buffer yang tersedia.
@@ -20,11 +26,12 @@ type BufferPoolManager struct {
 	replacer     *LRUReplacer         // LRU replacer buat evict least recently used page dari buffer pool.
 	nextBlockId  int
 	workerQueue  concurrent.WorkQueue
+	pageFileName string // nama file page tree, buat blockID page baru di NewPage.
 }
 
 // NewBufferPoolManager. initialize buffer pool manager.
 func NewBufferPoolManager(numBuffers int, diskManager DiskManager,
-	logManager LogManager, nextBlockId int) *BufferPoolManager {
+	logManager LogManager, nextBlockId int, pageFileName string) *BufferPoolManager {
 	bufferPool := make([]*Buffer, numBuffers)
 	for i := 0; i < numBuffers; i++ {
 		bufferPool[i] = NewBuffer(diskManager, logManager)
@@ -39,7 +46,7 @@ func NewBufferPoolManager(numBuffers int, diskManager DiskManager,
 
 	return &BufferPoolManager{bufferPool: bufferPool, numAvailable: numBuffers,
 		poolSize: numBuffers, bufferTable: make(map[disk.BlockID]int), freeList: fl, replacer: NewLRUReplacer(numBuffers), nextBlockId: nextBlockId,
-		workerQueue: backgroundFileWriter}
+		workerQueue: backgroundFileWriter, pageFileName: pageFileName}
 }
 
 func (bpm *BufferPoolManager) getBufferAvailable() int {
@@ -58,8 +65,8 @@ func (bpm *BufferPoolManager) GetNextBlockId() int {
 func (bpm *BufferPoolManager) FlushAll() error {
 	for _, buffer := range bpm.bufferPool {
 
-		if buffer.blockID.GetFilename() == "" || buffer.blockID.GetBlockNum() == 0 {
-			// unfilled frameId / free slot in buffer pool
+		if buffer.blockID.GetFilename() == "" || buffer.blockID == disk.NewBlockID(bpm.pageFileName, 0) {
+			// unfilled frameId / free slot in buffer pool, meta page ditulis langsung ke disk
 			continue
 		}
 
@@ -142,7 +149,7 @@ func (bpm *BufferPoolManager) FetchPage(blockID disk.BlockID) (*Buffer, error) {
 		if !bpm.replacer.Victim(&frameID) {
 			// frameID berisi least recently used buffer/page
 			// kalau tidak ada buffer yang bisa di evict, return err
-			return nil, fmt.Errorf("no available frame")
+			return nil, ErrNoAvailableFrame
 		}
 	}
 
@@ -155,6 +162,11 @@ func (bpm *BufferPoolManager) FetchPage(blockID disk.BlockID) (*Buffer, error) {
 
 	err := replacedBuffer.assignToBlock(blockID, bpm.workerQueue) // flush buffer sebelumnya & assign buffer ke page yang baru & set pin = 0
 	if err != nil {
+		// balikin frame ke freeList biar gak ada mapping ke page yang gagal diread
+		delete(bpm.bufferTable, blockID)
+		replacedBuffer.blockID = disk.BlockID{}
+		replacedBuffer.setDirty(false)
+		bpm.freeList = append(bpm.freeList, frameID)
 		return nil, fmt.Errorf("failed to assign buffer to block %w", err)
 	}
 	replacedBuffer.incrementPin()
@@ -177,7 +189,7 @@ func (bpm *BufferPoolManager) PinPage(blockID disk.BlockID) (*Buffer, error) {
 
 	if allPinned {
 		// semua page pinned/used oleh thread lain,return nil
-		return nil, fmt.Errorf("all pages are pinned")
+		return nil, ErrAllPagesPinned
 	}
 
 	var frameID int = 0
@@ -189,7 +201,7 @@ func (bpm *BufferPoolManager) PinPage(blockID disk.BlockID) (*Buffer, error) {
 	} else {
 		// ambil frameID dari evicted buffer di lru replacer
 		if !bpm.replacer.Victim(&frameID) {
-			return nil, fmt.Errorf("no available frame")
+			return nil, ErrNoAvailableFrame
 		}
 
 		bpm.bufferPool[frameID].ResetMemory()
@@ -225,7 +237,7 @@ func (bpm *BufferPoolManager) NewPage(blockID *disk.BlockID) (*Buffer, error) {
 	}
 
 	if allPinned {
-		return nil, fmt.Errorf("all pages are pinned")
+		return nil, ErrAllPagesPinned
 	}
 
 	var frameID int
@@ -237,7 +249,7 @@ func (bpm *BufferPoolManager) NewPage(blockID *disk.BlockID) (*Buffer, error) {
 	} else {
 		// ambil frameID dari evicted buffer di lru replacer
 		if !bpm.replacer.Victim(&frameID) {
-			return nil, fmt.Errorf("no available frame")
+			return nil, ErrNoAvailableFrame
 		}
 		if bpm.bufferPool[frameID].getIsDirty() && bpm.bufferPool[frameID].blockID != (disk.BlockID{}) {
 			// kalau page yang di evict dari buffer pool dirty (habis diupdate), flush page tsb
@@ -256,7 +268,7 @@ func (bpm *BufferPoolManager) NewPage(blockID *disk.BlockID) (*Buffer, error) {
 		bpm.nextBlockId++
 	}
 
-	*blockID = disk.NewBlockID(lib.PAGE_FILE_NAME, bpm.nextBlockId) // create new blockID
+	*blockID = disk.NewBlockID(bpm.pageFileName, bpm.nextBlockId) // create new blockID
 	bpm.nextBlockId++
 
 	bpm.bufferPool[frameID].blockID = *blockID
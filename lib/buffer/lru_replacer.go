@@ -58,13 +58,11 @@ func (d *DoubleLinkedList) GetBack() *ListNode {
 	return d.tail.prev
 }
 
-// Size. return jumlah node dalam list
+// Size. return jumlah node dalam list, tanpa head & tail
 func (d *DoubleLinkedList) Size() int {
 	size := 0
-	curr := d.head
-	for curr != nil {
+	for curr := d.head.next; curr != d.tail; curr = curr.next {
 		size++
-		curr = curr.next
 	}
 	return size
 }
@@ -2,9 +2,10 @@ package lib
 
 var (
 	MAX_BUFFER_POOL_SIZE_IN_MB = 100
+	BULK_LOAD_MEMORY_IN_MB     = 64
 	MAX_PAGE_SIZE              = 4096
 	MAX_BUFFER_POOL_SIZE       = MAX_BUFFER_POOL_SIZE_IN_MB * 1024 * 1024 / MAX_PAGE_SIZE
-	PAGE_SIZE_ARRAY            = []int{1024, 2048, 4096, 8192} // in bytes
+	PAGE_SIZE_ARRAY            = []int{1024, 2048, 4096, 8192, 16384, 32768} // in bytes. payload offset di node page uint16, jadi maksimal < 64KB
 
 )
 
@@ -12,5 +13,6 @@ const (
 	DB_DIR         = "go_rtreed_db"
 	PAGE_FILE_NAME = "go_rtreed.page"
 	LOG_FILE_NAME  = "go_rtreed.log"
+	ID_FILE_NAME   = "go_rtreed.ids"
 	NEW_PAGE_NUM   = 2 // initial new page num is 2 (0 is meta, 1 is root)
 )
@@ -6,6 +6,8 @@ import (
 	"sync"
 )
 
+var ErrBlockOutOfRange = errors.New("read block out of range")
+
 type DiskManager struct {
 	dbDir     string
 	blockSize int
@@ -17,7 +19,7 @@ type DiskManager struct {
 func NewDiskManager(dbDir string, blockSize int) *DiskManager {
 	_, err := os.Stat(dbDir)
 	if os.IsNotExist(err) {
-		os.Mkdir(dbDir, 0755)
+		os.MkdirAll(dbDir, 0755)
 	}
 
 	return &DiskManager{
@@ -30,10 +32,10 @@ func NewDiskManager(dbDir string, blockSize int) *DiskManager {
 
 // Read. membaca satu block page dari disk.
 func (dm *DiskManager) Read(blockID BlockID, page *Page) error {
-	filename := dm.dbDir + "/" + blockID.GetFilename()
-	if filename == "go_rtreed_db/" {
+	if blockID.GetFilename() == "" {
 		return nil
 	}
+	filename := dm.dbDir + "/" + blockID.GetFilename()
 	f, err := dm.getFile(filename) // open file dengan nama filename
 	if err != nil {
 		return err
@@ -46,7 +48,7 @@ func (dm *DiskManager) Read(blockID BlockID, page *Page) error {
 	}
 	fiSize := fi.Size()
 	if int64((blockID.GetBlockNum()+1)*dm.blockSize) > fiSize {
-		return errors.New("read block out of range")
+		return ErrBlockOutOfRange
 	}
 
 	// Seek ke posisi blockID * blockSize
@@ -112,7 +114,7 @@ func (dm *DiskManager) Append(fileName string) (BlockID, error) {
 
 // blockLength. return jumlah block page pada file.
 func (dm *DiskManager) BlockLength(fileName string) (int, error) {
-	f, err := dm.getFile(fileName)
+	f, err := dm.getFile(dm.dbDir + "/" + fileName)
 	if err != nil {
 		return 0, err
 	}
@@ -155,8 +157,15 @@ func (dm *DiskManager) GetDBDir() string {
 }
 
 func (dm *DiskManager) Close() error {
-	for _, f := range dm.openFiles {
-		return f.Close()
+	dm.latch.Lock()
+	defer dm.latch.Unlock()
+
+	var err error
+	for filename, f := range dm.openFiles {
+		if cerr := f.Close(); cerr != nil && err == nil {
+			err = cerr
+		}
+		delete(dm.openFiles, filename)
 	}
-	return nil
+	return err
 }
@@ -94,6 +94,32 @@ func (p *Page) Contents() []byte {
 	return p.bb.Bytes()
 }
 
+// nodeHeaderSize. isLeaf (1) + entries (2) + level (2) + parent (8) + pageNum (8) + dims (1).
+const nodeHeaderSize = 22
+
+// EntryPayloadSize. tag (1) + extent (16*dims) + rect (16*dims) + count (8) + id (8) + lhv (16) + sLen (4) + len (4),
+// tanpa data.
+func EntryPayloadSize(dims int) int {
+	return 1 + 2*rectSize(dims) + 8 + 8 + lhvSize + 4*2
+}
+
+// lhvSize. largest hilbert value entry, hi lalu lo.
+const lhvSize = 16
+
+// NodePageSize returns the page size needed by a node of maxEntries entries with dims axes and payloads of
+// up to maxSpatialDataInBytes bytes.
+func NodePageSize(dims, maxEntries, maxSpatialDataInBytes int) int {
+	return nodeHeaderSize + maxEntries*(types.BlockNumSize+2+EntryPayloadSize(dims)+maxSpatialDataInBytes)
+}
+
+// timedTag. bit di tag geometry entry yang punya interval waktu, axis terakhir extent nya adalah interval itu.
+const timedTag = 0x80
+
+// rectSize. lower & upper bound tiap axis.
+func rectSize(dims int) int {
+	return 8 * 2 * dims
+}
+
 func (p *Page) SerializeNode(node *tree.Node) {
 
 	isLeaf := node.IsLeaf()
@@ -103,8 +129,17 @@ func (p *Page) SerializeNode(node *tree.Node) {
 	p.PutUint64(5, uint64(node.GetParent()))
 	p.PutUint64(13, uint64(node.GetPageNum()))
 
-	leftPos := int32(21)
-	// max_page_size =   21 bytes + maxEntries * (10 + 48 + 8 + maxSpatialDataInBytes) bytes size
+	// semua entry satu tree punya jumlah axis yang sama
+	dims := 2
+	node.ForEntries(func(entry *tree.Entry) {
+		dims = max(dims, entry.GetRect().Dims())
+	})
+	p.bb.Bytes()[21] = byte(dims)
+	rectBytes := rectSize(dims)
+	payloadSize := EntryPayloadSize(dims)
+
+	leftPos := int32(nodeHeaderSize)
+	// max_page_size = NodePageSize(dims, maxEntries, maxSpatialDataInBytes)
 	rightPos := len(p.bb.Bytes()) - 1
 	node.ForEntries(func(entry *tree.Entry) {
 
@@ -116,9 +151,7 @@ func (p *Page) SerializeNode(node *tree.Node) {
 		enObj := entry.GetObject()
 		sLen := len(enObj.Data())
 
-		payloadSize := 8*6 + sLen + 4*2
-
-		offset := rightPos - payloadSize
+		offset := rightPos - (payloadSize + sLen)
 
 		p.PutUint16(leftPos, uint16(offset))
 		leftPos += 2
@@ -129,29 +162,41 @@ func (p *Page) SerializeNode(node *tree.Node) {
 		rightPos -= 4
 		p.PutInt(int32(rightPos), int32(sLen))
 
-		rightPos -= 8
-		p.PutUint64(int32(rightPos), math.Float64bits(entry.GetRect().GetSLon()))
-		rightPos -= 8
-		p.PutUint64(int32(rightPos), math.Float64bits(entry.GetRect().GetTLon()))
+		rightPos -= lhvSize
+		lhv := entry.GetLHV()
+		p.PutUint64(int32(rightPos), lhv.Hi)
+		p.PutUint64(int32(rightPos+8), lhv.Lo)
 
 		rightPos -= 8
-		p.PutUint64(int32(rightPos), math.Float64bits(entry.GetRect().GetSLat()))
-		rightPos -= 8
-		p.PutUint64(int32(rightPos), math.Float64bits(entry.GetRect().GetTLat()))
-
-		var llat, llon float64
+		p.PutUint64(int32(rightPos), enObj.ID())
 
-		llat = enObj.Location().Lat
-		llon = enObj.Location().Lon
-		rightPos -= 8
-		p.PutUint64(int32(rightPos), math.Float64bits(llat))
 		rightPos -= 8
+		p.PutUint64(int32(rightPos), uint64(entry.GetCount()))
 
-		p.PutUint64(int32(rightPos), math.Float64bits(llon))
+		rightPos -= rectBytes
+		p.putRect(int32(rightPos), entry.GetRect(), dims)
 
+		// true extent object, rect leaf entry = extent diperbesar tol
+		extent, tag := enObj.Extent(), byte(enObj.GeometryType())
+		if start, end, ok := enObj.TimeCoords(); ok {
+			extent, tag = extent.AppendAxis(start, end), tag|timedTag
+		}
+		rightPos -= rectBytes
+		p.putRect(int32(rightPos), extent, dims)
+
+		rightPos -= 1
+		p.bb.Bytes()[rightPos] = tag
 	})
 }
 
+// putRect. urutan per axis: upper lalu lower, untuk 2 dimensi: tLat, sLat, tLon, sLon.
+func (p *Page) putRect(offset int32, r tree.Rect, dims int) {
+	for i := 0; i < dims; i++ {
+		p.PutUint64(offset+int32(16*i), math.Float64bits(r.Max(i)))
+		p.PutUint64(offset+int32(16*i+8), math.Float64bits(r.Min(i)))
+	}
+}
+
 func (p *Page) DeserializeNode() *tree.Node {
 	node := &tree.Node{}
 	isLeaf := p.GetBool(0)
@@ -163,55 +208,79 @@ func (p *Page) DeserializeNode() *tree.Node {
 	node.SetParent(types.BlockNum(p.GetUint64(5)))
 	node.SetPageNum(types.BlockNum(p.GetUint64(13)))
 
+	buf := p.bb.Bytes()
+	dims := nodeDims(buf)
 	entries := make([]*tree.Entry, entriesCount)
 	for i := 0; i < entriesCount; i++ {
-		entries[i] = &tree.Entry{}
-	}
-
-	leftPos := int32(21)
-	for i := 0; i < entriesCount; i++ {
+		entry := readEntry(entrySlotPos(i), buf, isLeaf, dims)
 
-		pageNum := types.BlockNum(p.GetUint64(leftPos))
-		leftPos += types.BlockNumSize
-		entries[i].SetChild(pageNum)
-
-		offset := p.GetUint16(leftPos)
-		leftPos += 2
+		// payload dicopy, node hasil deserialize tidak boleh nge-refer ke page
+		obj := entry.GetObject()
+		obj.SetData(bytes.Clone(obj.Data()))
+		entry.SetObject(obj)
+		entries[i] = &entry
+	}
 
-		locLon := math.Float64frombits(p.GetUint64(int32(offset)))
-		offset += 8
-		locLat := math.Float64frombits(p.GetUint64(int32(offset)))
-		offset += 8
+	node.SetEntries(entries)
+	return node
+}
 
-		rrect := &tree.Rect{}
-		tLat := math.Float64frombits(p.GetUint64(int32(offset)))
-		offset += 8
-		SLat := math.Float64frombits(p.GetUint64(int32(offset)))
-		offset += 8
-		tLon := math.Float64frombits(p.GetUint64(int32(offset)))
-		offset += 8
-		sLon := math.Float64frombits(p.GetUint64(int32(offset)))
-		offset += 8
+// entrySlotPos. posisi slot (child 8 bytes + offset payload 2 bytes) entry ke-i.
+func entrySlotPos(i int) int32 {
+	return int32(nodeHeaderSize + i*(types.BlockNumSize+2))
+}
 
-		rrect.SetSLat(SLat)
-		rrect.SetSLon(sLon)
-		rrect.SetTLat(tLat)
-		rrect.SetTLon(tLon)
-		entries[i].SetRect(*rrect)
+// nodeDims. jumlah axis rect di page node.
+func nodeDims(buf []byte) int {
+	return int(buf[21])
+}
 
-		sLen := p.GetInt(int32(offset))
-		offset += 4
+// readEntry. decode entry dari slot nya. payload object nge-refer ke buf (tanpa copy).
+func readEntry(slotPos int32, buf []byte, isLeaf bool, dims int) tree.Entry {
+	var entry tree.Entry
+	entry.SetChild(types.BlockNum(GetUint64(slotPos, buf)))
 
-		spatialData := p.GetBytes(int32(offset))
-		offset += uint16(sLen)
+	rectBytes := int32(rectSize(dims))
+	offset := int32(GetUint16(slotPos+types.BlockNumSize, buf))
+	entry.SetRect(getRect(offset+1+rectBytes, buf, dims))
+	entry.SetCount(int(GetUint64(offset+1+2*rectBytes, buf)))
+	lhvPos := offset + 1 + 2*rectBytes + 8 + 8
+	entry.SetLHV(tree.HilbertKey{Hi: GetUint64(lhvPos, buf), Lo: GetUint64(lhvPos+8, buf)})
 
-		entries[i].SetObject(tree.NewSpatialData(tree.NewPoint(locLat, locLon),
-			spatialData))
+	data := ViewBytes(lhvPos+lhvSize+4, buf)
+	if isLeaf {
+		tag := buf[offset]
+		extent := getRect(offset+1, buf, dims)
+		var obj tree.SpatialData
+		if tag&timedTag == 0 {
+			obj = tree.NewSpatialDataWithExtent(tree.GeometryType(tag), extent, data)
+		} else {
+			obj = tree.NewSpatialDataWithExtent(tree.GeometryType(tag&^timedTag), extent.Project(dims-1), data)
+			obj = obj.WithTimeCoords(extent.Min(dims-1), extent.Max(dims-1))
+		}
+		entry.SetObject(obj.WithID(GetUint64(offset+1+2*rectBytes+8, buf)))
+	} else {
+		entry.SetObject(tree.NewSpatialData(tree.Point{}, data))
+	}
+	return entry
+}
 
+// getRect. kebalikan putRect.
+func getRect(offset int32, buf []byte, dims int) tree.Rect {
+	if dims == 2 {
+		tLat := math.Float64frombits(GetUint64(offset, buf))
+		sLat := math.Float64frombits(GetUint64(offset+8, buf))
+		tLon := math.Float64frombits(GetUint64(offset+16, buf))
+		sLon := math.Float64frombits(GetUint64(offset+24, buf))
+		return tree.NewRectFromBounds(sLat, sLon, tLat, tLon)
 	}
 
-	node.SetEntries(entries)
-	return node
+	var lower, upper [tree.MaxDims]float64
+	for i := 0; i < dims; i++ {
+		upper[i] = math.Float64frombits(GetUint64(offset+int32(16*i), buf))
+		lower[i] = math.Float64frombits(GetUint64(offset+int32(16*i+8), buf))
+	}
+	return tree.NewRectFromPoints(tree.NewPointN(lower[:dims]...), tree.NewPointN(upper[:dims]...))
 }
 
 type NodeByte struct {
@@ -226,102 +295,58 @@ func (nb *NodeByte) IsLeaf() bool {
 	return GetBool(0, nb.buf)
 }
 
+// ForEntries. iterate entries langsung dari bytes page. payload object di leaf entry nge-refer ke bytes page (tanpa copy),
+// jadi cuma valid selama page nya masih di pin.
 func (nb *NodeByte) ForEntries(f func(entry tree.Entry)) {
-
 	entriesCount := int(GetUint16(1, nb.buf))
-
-	leftPos := int32(21)
+	isLeaf := nb.IsLeaf()
+	dims := nodeDims(nb.buf)
 	for i := 0; i < entriesCount; i++ {
-
-		var entry tree.Entry
-		pageNum := types.BlockNum(GetUint64(leftPos, nb.buf))
-		leftPos += types.BlockNumSize
-		entry.SetChild(pageNum)
-
-		offset := GetUint16(leftPos, nb.buf)
-		leftPos += 2
-		locLon := math.Float64frombits(GetUint64(int32(offset), nb.buf))
-		offset += 8
-		locLat := math.Float64frombits(GetUint64(int32(offset), nb.buf))
-		offset += 8
-		rrect := &tree.Rect{}
-		tLat := math.Float64frombits(GetUint64(int32(offset), nb.buf))
-		offset += 8
-		SLat := math.Float64frombits(GetUint64(int32(offset), nb.buf))
-		offset += 8
-		tLon := math.Float64frombits(GetUint64(int32(offset), nb.buf))
-		offset += 8
-		sLon := math.Float64frombits(GetUint64(int32(offset), nb.buf))
-		offset += 8
-
-		rrect.SetSLat(SLat)
-		rrect.SetSLon(sLon)
-		rrect.SetTLat(tLat)
-		rrect.SetTLon(tLon)
-		entry.SetRect(*rrect)
-
-		// sLen := GetInt(int32(offset), nb.buf)
-		offset += 4
-
-		if nb.IsLeaf() {
-			// spatialData := GetBytes(int32(offset), nb.buf)
-			// offset += uint16(sLen) //
-
-			entry.SetObject(tree.NewSpatialData(tree.NewPoint(locLat, locLon),
-				[]byte{}))
-		}
-		f(entry)
+		f(readEntry(entrySlotPos(i), nb.buf, isLeaf, dims))
 	}
 }
 
+// ForEntriesOverlaps. iterate entries yang rect nya overlap dengan bound.
 func (nb *NodeByte) ForEntriesOverlaps(bound tree.Rect, onInternal func(child types.BlockNum),
-	onLeaf func(lat, lon float64, data []byte)) {
+	onLeaf func(obj tree.SpatialData)) {
+	nb.ForEntriesMatch(bound.Overlaps, onInternal, onLeaf)
+}
 
-	entriesCount := int(GetUint16(1, nb.buf))
+// ForEntriesMatch. iterate entries yang rect nya lolos match. payload object di onLeaf nge-refer ke bytes page (tanpa copy),
+// copy dulu kalau mau dipakai setelah page di unpin.
+func (nb *NodeByte) ForEntriesMatch(match func(rect tree.Rect) bool, onInternal func(child types.BlockNum),
+	onLeaf func(obj tree.SpatialData)) {
 
-	leftPos := int32(21)
+	entriesCount := int(GetUint16(1, nb.buf))
+	isLeaf := nb.IsLeaf()
+	dims := nodeDims(nb.buf)
+	rectBytes := int32(rectSize(dims))
 	for i := 0; i < entriesCount; i++ {
-
-		var entry tree.Entry
-		childPageNum := types.BlockNum(GetUint64(leftPos, nb.buf))
-		leftPos += types.BlockNumSize
-		entry.SetChild(childPageNum)
-
-		offset := GetUint16(leftPos, nb.buf)
-		leftPos += 2
-		locLon := math.Float64frombits(GetUint64(int32(offset), nb.buf))
-		offset += 8
-		locLat := math.Float64frombits(GetUint64(int32(offset), nb.buf))
-		offset += 8
-		rrect := &tree.Rect{}
-		tLat := math.Float64frombits(GetUint64(int32(offset), nb.buf))
-		offset += 8
-		SLat := math.Float64frombits(GetUint64(int32(offset), nb.buf))
-		offset += 8
-		tLon := math.Float64frombits(GetUint64(int32(offset), nb.buf))
-		offset += 8
-		sLon := math.Float64frombits(GetUint64(int32(offset), nb.buf))
-		offset += 8
-
-		rrect.SetSLat(SLat)
-		rrect.SetSLon(sLon)
-		rrect.SetTLat(tLat)
-		rrect.SetTLon(tLon)
-
-		if !rrect.Overlaps(bound) {
+		slotPos := entrySlotPos(i)
+		offset := int32(GetUint16(slotPos+types.BlockNumSize, nb.buf))
+		if !match(getRect(offset+1+rectBytes, nb.buf, dims)) {
 			continue
 		}
 
-		if nb.IsLeaf() {
-			onLeaf(locLat, locLon, []byte{})
+		if isLeaf {
+			entry := readEntry(slotPos, nb.buf, true, dims)
+			onLeaf(entry.GetObject())
 		} else {
-			onInternal(childPageNum)
+			onInternal(types.BlockNum(GetUint64(slotPos, nb.buf)))
 		}
 	}
 }
 
+// SerializeMetadata. layout meta page: magic(4) | version(2) | root(8) | freelistPage(8) | height(2) | size(4) | nextBlockId(4) |
+// dim(2) | minEntries(2) | maxEntries(2) | maxSpatialDataInBytes(4) | pageSize(4) | temporal(1) | nextID(8)
 func (p *Page) SerializeMetadata(m *meta.Meta) {
 	leftPos := int32(0)
+	p.PutInt(leftPos, int32(m.GetMagic()))
+	leftPos += 4
+
+	p.PutUint16(leftPos, m.GetVersion())
+	leftPos += 2
+
 	p.PutUint64(leftPos, uint64(m.GetRoot()))
 	leftPos += types.BlockNumSize
 
@@ -336,12 +361,38 @@ func (p *Page) SerializeMetadata(m *meta.Meta) {
 
 	p.PutInt(leftPos, int32(m.GetNextBlockId()))
 	leftPos += 4
+
+	p.PutUint16(leftPos, uint16(m.GetDim()))
+	leftPos += 2
+
+	p.PutUint16(leftPos, uint16(m.GetMinEntries()))
+	leftPos += 2
+
+	p.PutUint16(leftPos, uint16(m.GetMaxEntries()))
+	leftPos += 2
+
+	p.PutInt(leftPos, int32(m.GetMaxSpatialDataInBytes()))
+	leftPos += 4
+
+	p.PutInt(leftPos, int32(m.GetPageSize()))
+	leftPos += 4
+
+	p.PutBool(leftPos, m.IsTemporal())
+	leftPos += 1
+
+	p.PutUint64(leftPos, m.GetNextID())
 }
 
 func (p *Page) DeserializeMetadata() *meta.Meta {
 	m := meta.NewEmptyMeta()
 	leftPos := int32(0)
 
+	m.SetMagic(uint32(p.GetInt(leftPos)))
+	leftPos += 4
+
+	m.SetVersion(p.GetUint16(leftPos))
+	leftPos += 2
+
 	m.SetRoot(types.BlockNum(p.GetUint64(leftPos)))
 	leftPos += types.BlockNumSize
 
@@ -355,6 +406,27 @@ func (p *Page) DeserializeMetadata() *meta.Meta {
 	leftPos += 4
 
 	m.SetNextBlockId(int(p.GetInt(leftPos)))
+	leftPos += 4
+
+	m.SetDim(int(p.GetUint16(leftPos)))
+	leftPos += 2
+
+	m.SetMinEntries(int(p.GetUint16(leftPos)))
+	leftPos += 2
+
+	m.SetMaxEntries(int(p.GetUint16(leftPos)))
+	leftPos += 2
+
+	m.SetMaxSpatialDataInBytes(int(p.GetInt(leftPos)))
+	leftPos += 4
+
+	m.SetPageSize(int(p.GetInt(leftPos)))
+	leftPos += 4
+
+	m.SetTemporal(p.GetBool(leftPos))
+	leftPos += 1
+
+	m.SetNextID(p.GetUint64(leftPos))
 
 	return m
 }
@@ -428,3 +500,9 @@ func GetBytes(offset int32, buf []byte) []byte {
 	copy(b, buf[offset+4:offset+4+length])
 	return b
 }
+
+// ViewBytes. sama seperti GetBytes tapi tanpa copy, return slice yang nge-refer langsung ke buf.
+func ViewBytes(offset int32, buf []byte) []byte {
+	length := GetInt(offset, buf)
+	return buf[offset+4 : offset+4+length : offset+4+length]
+}
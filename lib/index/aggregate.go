@@ -0,0 +1,81 @@
+package index
+
+import (
+	"math"
+
+	"github.com/lintang-b-s/rtreed/lib/tree"
+	"github.com/lintang-b-s/rtreed/types"
+)
+
+// Aggregate splits rect into a cellsX (longitude) by cellsY (latitude) grid and counts the objects whose location
+// falls in each cell.
+// the result is indexed [y][x], row 0 is the southern edge of rect and column 0 the western one. an object on a
+// cell border belongs to the cell north/east of it, except on rect's own northern and eastern edges.
+//
+// a subtree whose MBR falls inside one cell adds its stored count to that cell without being read, only subtrees
+// spanning several cells are descended. rect must have as many axes as the tree, ErrDimMismatch otherwise.
+func (rt *Rtreed) Aggregate(rect tree.Rect, cellsX, cellsY int) ([][]int, error) {
+	if cellsX <= 0 || cellsY <= 0 {
+		return nil, ErrInvalidGrid
+	}
+	if rect.Dims() != rt.dim {
+		return nil, ErrDimMismatch
+	}
+
+	grid := make([][]int, cellsY)
+	for y := range grid {
+		grid[y] = make([]int, cellsX)
+	}
+	cellOf := func(p tree.Point) (int, int) {
+		return gridCell(p.Lon, rect.GetSLon(), rect.GetTLon(), cellsX), gridCell(p.Lat, rect.GetSLat(), rect.GetTLat(), cellsY)
+	}
+
+	stack := []types.BlockNum{rt.root}
+	for len(stack) > 0 {
+		nPageNum := stack[len(stack)-1]
+		stack = stack[:len(stack)-1]
+
+		node, err := rt.getNodeByte(nPageNum)
+		if err != nil {
+			return nil, err
+		}
+		isLeaf := node.IsLeaf()
+		node.ForEntries(func(e tree.Entry) {
+			if isLeaf {
+				obj := e.GetObject()
+				loc := obj.Location()
+				if !rect.ContainRect(loc.ToRect(0)) {
+					return
+				}
+				x, y := cellOf(loc)
+				grid[y][x]++
+				return
+			}
+			r := e.GetRect()
+			if !rect.Overlaps(r) {
+				return
+			}
+
+			if rect.ContainRect(r) {
+				sx, sy := cellOf(tree.NewPoint(r.GetSLat(), r.GetSLon()))
+				tx, ty := cellOf(tree.NewPoint(r.GetTLat(), r.GetTLon()))
+				if sx == tx && sy == ty {
+					grid[sy][sx] += e.GetCount()
+					return
+				}
+			}
+			stack = append(stack, e.GetChild())
+		})
+		rt.unpin(nPageNum, false)
+	}
+	return grid, nil
+}
+
+// gridCell. index cell v di range [lo, hi] yang dibagi n cell. v == hi masuk cell terakhir.
+func gridCell(v, lo, hi float64, n int) int {
+	if hi <= lo {
+		return 0
+	}
+	i := int(math.Floor((v - lo) / (hi - lo) * float64(n)))
+	return max(0, min(i, n-1))
+}
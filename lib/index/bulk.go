@@ -0,0 +1,270 @@
+package index
+
+import (
+	"iter"
+	"math"
+	"path/filepath"
+	"sort"
+
+	"github.com/lintang-b-s/rtreed/lib"
+	"github.com/lintang-b-s/rtreed/lib/tree"
+	"github.com/lintang-b-s/rtreed/types"
+)
+
+// BulkLoad fills an empty tree with objs in one pass, much faster than inserting them one by one. it returns
+// ErrNotEmpty when the tree already holds objects. the objects are packed with Sort-Tile-Recursive: sorted into slabs
+// along each axis in turn and cut into full leaves of MaxEntries, then every upper level is packed the same way from
+// the rectangles of the level below. objects get ids like InsertID, in the order objs yields them, and must pass
+// the checks of Insert. all objects are held in memory while the tree is built.
+func (rt *Rtreed) BulkLoad(objs iter.Seq[tree.SpatialData]) error {
+	if rt.size != 0 {
+		return ErrNotEmpty
+	}
+
+	nextID := rt.metadata.GetNextID()
+	entries := make([]*tree.Entry, 0, 1024)
+	for obj := range objs {
+		if err := rt.checkObject(obj); err != nil {
+			return err
+		}
+		obj = obj.WithID(nextID + uint64(len(entries)))
+		entries = append(entries, tree.NewEntry(obj.Bounds(), lib.NEW_PAGE_NUM, obj))
+	}
+	if len(entries) == 0 {
+		return nil
+	}
+
+	err := rt.packLevels(entries, 1, func(entries []*tree.Entry) [][]*tree.Entry {
+		groups := strPack(entries, 0, entries[0].GetRect().Dims(), rt.maxEntries)
+		return balanceGroups(groups, rt.minEntries, rt.maxEntries)
+	})
+	if err != nil {
+		return err
+	}
+
+	rt.size = int32(len(entries))
+	rt.metadata.SetNextID(nextID + uint64(len(entries)))
+	rt.upateMetaRoot(rt.root)
+	rt.updateMetaHeightSeize(rt.height, rt.size)
+	return nil
+}
+
+// BulkLoadHilbert fills an empty tree like BulkLoad, for inputs larger than memory. objects are sorted by the Hilbert
+// value of their center with an external merge sort: sorted runs of at most Options.BulkLoadMemoryInMB are written as
+// temporary files next to the page file (removed when the load ends) and merged, and the merged stream is packed
+// into full leaves and upper levels as it goes, so memory stays within the budget whatever the input size.
+// objects get ids in the order objs yields them.
+func (rt *Rtreed) BulkLoadHilbert(objs iter.Seq[tree.SpatialData]) (err error) {
+	if rt.size != 0 {
+		return ErrNotEmpty
+	}
+
+	sorter := newExternalSorter(rt.diskManager.GetDBDir(), filepath.Base(rt.pageFileName), rt.bulkLoadMemory)
+	defer func() {
+		if cerr := sorter.cleanup(); err == nil {
+			err = cerr
+		}
+	}()
+
+	nextID, n := rt.metadata.GetNextID(), uint64(0)
+	for obj := range objs {
+		if err := rt.checkObject(obj); err != nil {
+			return err
+		}
+		obj = obj.WithID(nextID + n)
+		if err := sorter.add(tree.NewEntry(obj.Bounds(), lib.NEW_PAGE_NUM, obj)); err != nil {
+			return err
+		}
+		n++
+	}
+	if n == 0 {
+		return nil
+	}
+
+	packer := &levelPacker{rt: rt}
+	err = sorter.sorted(func(e *tree.Entry) error {
+		return packer.add(e, 1)
+	})
+	if err != nil {
+		return err
+	}
+	if err := packer.finish(); err != nil {
+		return err
+	}
+
+	rt.size = int32(n)
+	rt.metadata.SetNextID(nextID + n)
+	rt.upateMetaRoot(rt.root)
+	rt.updateMetaHeightSeize(rt.height, rt.size)
+	return nil
+}
+
+// levelPacker. pack entry yang datang terurut bottom-up tanpa menampung satu level penuh: entry tiap level
+// ditampung sampai ada maxEntries+minEntries, maxEntries pertama ditulis jadi satu node & entry parent nya naik ke
+// level atas. sisa minEntries menjamin node terakhir tiap level tidak underfull.
+type levelPacker struct {
+	rt      *Rtreed
+	pending [][]*tree.Entry // pending[i]: entry node level i+1 yang belum ditulis
+	written []bool          // written[i]: sudah ada node level i+1 yang ditulis
+}
+
+func (p *levelPacker) add(e *tree.Entry, level int) error {
+	for len(p.pending) < level {
+		p.pending = append(p.pending, make([]*tree.Entry, 0, p.rt.maxEntries+p.rt.minEntries))
+		p.written = append(p.written, false)
+	}
+
+	i := level - 1
+	p.pending[i] = append(p.pending[i], e)
+	if len(p.pending[i]) < p.rt.maxEntries+p.rt.minEntries {
+		return nil
+	}
+	full := p.pending[i][:p.rt.maxEntries]
+	rest := make([]*tree.Entry, 0, p.rt.maxEntries+p.rt.minEntries)
+	p.pending[i] = append(rest, p.pending[i][p.rt.maxEntries:]...)
+	return p.writeNode(full, level)
+}
+
+// writeNode. tulis node level berisi entries, entry parent nya masuk level atas.
+func (p *levelPacker) writeNode(entries []*tree.Entry, level int) error {
+	parent, err := p.rt.writePackedNode(entries, level, lib.NEW_PAGE_NUM)
+	if err != nil {
+		return err
+	}
+	p.written[level-1] = true
+	return p.add(parent, level+1)
+}
+
+// finish. tulis sisa entry tiap level dari bawah. level yang belum pernah menulis node adalah level teratas, kalau
+// sisa nya muat satu node, node itu jadi root di page root. sisa yang lebih dari maxEntries dibagi dua node.
+func (p *levelPacker) finish() error {
+	for level := 1; ; level++ {
+		rest := p.pending[level-1]
+		if !p.written[level-1] && len(rest) <= p.rt.maxEntries {
+			if _, err := p.rt.writePackedNode(rest, level, p.rt.root); err != nil {
+				return err
+			}
+			p.rt.height = level - 1
+			return nil
+		}
+
+		if len(rest) > p.rt.maxEntries {
+			if err := p.writeNode(rest[:len(rest)/2], level); err != nil {
+				return err
+			}
+			rest = rest[len(rest)/2:]
+		}
+		if err := p.writeNode(rest, level); err != nil {
+			return err
+		}
+	}
+}
+
+// packLevels. bangun tree bottom-up dari entries level: tiap group hasil pack jadi satu node di level itu, entry
+// parent nya jadi entries level berikutnya, sampai tinggal satu node yang ditulis ke page root.
+func (rt *Rtreed) packLevels(entries []*tree.Entry, level int, pack func(entries []*tree.Entry) [][]*tree.Entry) error {
+	for {
+		groups := pack(entries)
+		if len(groups) == 1 {
+			_, err := rt.writePackedNode(groups[0], level, rt.root)
+			if err != nil {
+				return err
+			}
+			rt.height = level - 1
+			return nil
+		}
+
+		parents := make([]*tree.Entry, 0, len(groups))
+		for _, group := range groups {
+			parent, err := rt.writePackedNode(group, level, lib.NEW_PAGE_NUM)
+			if err != nil {
+				return err
+			}
+			parents = append(parents, parent)
+		}
+		entries, level = parents, level+1
+	}
+}
+
+// writePackedNode. tulis node level berisi entries ke pageNum (page baru kalau lib.NEW_PAGE_NUM), update parent
+// pointer child nya atau id index object nya, return entry parent untuk node tsb. page nya langsung diunpin.
+func (rt *Rtreed) writePackedNode(entries []*tree.Entry, level int, pageNum types.BlockNum) (*tree.Entry, error) {
+	needToUnpin := make([]unpinPage, 0, len(entries)+1)
+	defer func() {
+		rt.unpinAll(needToUnpin)
+	}()
+
+	n := tree.NewNode(entries, 0, level, level == 1)
+	n.SetPageNum(pageNum)
+	n, _, err := rt.writeNodeAndGetPage(n, &needToUnpin)
+	if err != nil {
+		return nil, err
+	}
+
+	if n.IsLeaf() {
+		err = rt.setLeafIDs(entries, n.GetPageNum())
+		if err != nil {
+			return nil, err
+		}
+	} else {
+		for _, e := range entries {
+			err = rt.setParent(e.GetChild(), n.GetPageNum(), &needToUnpin)
+			if err != nil {
+				return nil, err
+			}
+		}
+	}
+	return parentEntryFor(n), nil
+}
+
+// strPack. Sort-Tile-Recursive: entries diurutkan berdasarkan titik tengah rect di axis lalu dipotong jadi slab, tiap
+// slab diulang di axis berikutnya. di axis terakhir slab dipotong per capacity entry.
+func strPack(entries []*tree.Entry, axis, dims, capacity int) [][]*tree.Entry {
+	sort.SliceStable(entries, func(i, j int) bool {
+		ri, rj := entries[i].GetRect(), entries[j].GetRect()
+		return ri.Min(axis)+ri.Max(axis) < rj.Min(axis)+rj.Max(axis)
+	})
+
+	if axis == dims-1 {
+		groups := make([][]*tree.Entry, 0, (len(entries)+capacity-1)/capacity)
+		for start := 0; start < len(entries); start += capacity {
+			groups = append(groups, entries[start:min(len(entries), start+capacity)])
+		}
+		return groups
+	}
+
+	// jumlah slab = akar ke-(axis tersisa) dari jumlah node
+	nodes := (len(entries) + capacity - 1) / capacity
+	slabs := int(math.Ceil(math.Pow(float64(nodes), 1/float64(dims-axis)) - 1e-9))
+	slabSize := capacity * ((nodes + slabs - 1) / slabs)
+
+	groups := make([][]*tree.Entry, 0, nodes)
+	for start := 0; start < len(entries); start += slabSize {
+		groups = append(groups, strPack(entries[start:min(len(entries), start+slabSize)], axis+1, dims, capacity)...)
+	}
+	return groups
+}
+
+// balanceGroups. group di ujung slab bisa kurang dari minEntries. group tsb digabung dengan group sebelumnya, kalau
+// hasilnya lebih dari maxEntries dibagi dua (keduanya >= minEntries karena maxEntries >= 2*minEntries).
+func balanceGroups(groups [][]*tree.Entry, minEntries, maxEntries int) [][]*tree.Entry {
+	balanced := make([][]*tree.Entry, 0, len(groups))
+	for _, group := range groups {
+		last := len(balanced) - 1
+		if last < 0 || (len(balanced[last]) >= minEntries && len(group) >= minEntries) {
+			balanced = append(balanced, group)
+			continue
+		}
+
+		prev := balanced[last]
+		merged := append(prev[:len(prev):len(prev)], group...)
+		if len(merged) <= maxEntries {
+			balanced[last] = merged
+			continue
+		}
+		half := len(merged) / 2
+		balanced[last] = merged[:half]
+		balanced = append(balanced, merged[half:])
+	}
+	return balanced
+}
@@ -10,7 +10,7 @@ import (
 )
 
 func (rt *Rtreed) getNode(pageNum types.BlockNum) (*tree.Node, error) {
-	buffer, err := rt.bufferPoolManager.FetchPage(disk.NewBlockID(lib.PAGE_FILE_NAME, int(pageNum)))
+	buffer, err := rt.bufferPoolManager.FetchPage(disk.NewBlockID(rt.pageFileName, int(pageNum)))
 
 	if err != nil {
 		return nil, err
@@ -22,7 +22,7 @@ func (rt *Rtreed) getNode(pageNum types.BlockNum) (*tree.Node, error) {
 }
 
 func (rt *Rtreed) getNodeAndPage(pageNum types.BlockNum) (*tree.Node, *buffer.Buffer, error) {
-	buffer, err := rt.bufferPoolManager.FetchPage(disk.NewBlockID(lib.PAGE_FILE_NAME, int(pageNum)))
+	buffer, err := rt.bufferPoolManager.FetchPage(disk.NewBlockID(rt.pageFileName, int(pageNum)))
 
 	if err != nil {
 		return nil, nil, err
@@ -34,7 +34,7 @@ func (rt *Rtreed) getNodeAndPage(pageNum types.BlockNum) (*tree.Node, *buffer.Bu
 }
 
 func (rt *Rtreed) getNodeByte(pageNum types.BlockNum) (*disk.NodeByte, error) {
-	buffer, err := rt.bufferPoolManager.FetchPage(disk.NewBlockID(lib.PAGE_FILE_NAME, int(pageNum)))
+	buffer, err := rt.bufferPoolManager.FetchPage(disk.NewBlockID(rt.pageFileName, int(pageNum)))
 
 	if err != nil {
 		return nil, err
@@ -52,7 +52,7 @@ func (rt *Rtreed) writeRootNode(n *tree.Node) (*tree.Node, error) {
 	if err != nil {
 		return nil, err
 	}
-	blockId.SetFileName(lib.PAGE_FILE_NAME)
+	blockId.SetFileName(rt.pageFileName)
 
 	n.SetPageNum(types.BlockNum(blockId.GetBlockNum()))
 	buffer.SerializeNode(n)
@@ -63,38 +63,9 @@ func (rt *Rtreed) writeRootNode(n *tree.Node) (*tree.Node, error) {
 	return n, nil
 }
 
-func (rt *Rtreed) writeNode(n *tree.Node) (*tree.Node, error) {
-	var (
-		blockId disk.BlockID
-	)
-	if n.GetPageNum() == lib.NEW_PAGE_NUM {
-		buffer, err := rt.bufferPoolManager.NewPage(&blockId)
-		if err != nil {
-			return nil, err
-		}
-		blockId.SetFileName(lib.PAGE_FILE_NAME)
-
-		n.SetPageNum(types.BlockNum(blockId.GetBlockNum()))
-		buffer.SerializeNode(n)
-		err = rt.diskManager.Write(blockId, buffer.GetContents())
-		if err != nil {
-			return nil, err
-		}
-		return n, nil
-	} else {
-		blockId = disk.NewBlockID(lib.PAGE_FILE_NAME, int(n.GetPageNum()))
-		buffer, err := rt.bufferPoolManager.FetchPage(blockId)
-		if err != nil {
-			return nil, err
-		}
-		buffer.SerializeNode(n)
-
-		return n, nil
-	}
-
-}
-
-func (rt *Rtreed) writeNodeAndGetPage(n *tree.Node) (*tree.Node, *buffer.Buffer, error) {
+// writeNodeAndGetPage. write node ke page nya. node baru (pageNum == NEW_PAGE_NUM) dapat page baru dari buffer pool.
+// page yang dipakai dicatat di needToUnpin sebagai dirty page.
+func (rt *Rtreed) writeNodeAndGetPage(n *tree.Node, needToUnpin *[]unpinPage) (*tree.Node, *buffer.Buffer, error) {
 	var (
 		blockId disk.BlockID
 	)
@@ -103,8 +74,9 @@ func (rt *Rtreed) writeNodeAndGetPage(n *tree.Node) (*tree.Node, *buffer.Buffer,
 		if err != nil {
 			return nil, nil, err
 		}
-		blockId.SetFileName(lib.PAGE_FILE_NAME)
+		blockId.SetFileName(rt.pageFileName)
 		n.SetPageNum(types.BlockNum(blockId.GetBlockNum()))
+		*needToUnpin = append(*needToUnpin, newUnpinPage(n.GetPageNum(), true))
 
 		buffer.SerializeNode(n)
 		err = rt.diskManager.Write(blockId, buffer.GetContents())
@@ -113,11 +85,12 @@ func (rt *Rtreed) writeNodeAndGetPage(n *tree.Node) (*tree.Node, *buffer.Buffer,
 		}
 		return n, buffer, nil
 	} else {
-		blockId = disk.NewBlockID(lib.PAGE_FILE_NAME, int(n.GetPageNum()))
+		blockId = disk.NewBlockID(rt.pageFileName, int(n.GetPageNum()))
 		buffer, err := rt.bufferPoolManager.FetchPage(blockId)
 		if err != nil {
 			return nil, nil, err
 		}
+		*needToUnpin = append(*needToUnpin, newUnpinPage(n.GetPageNum(), true))
 		buffer.SerializeNode(n)
 
 		return n, buffer, nil
@@ -125,23 +98,79 @@ func (rt *Rtreed) writeNodeAndGetPage(n *tree.Node) (*tree.Node, *buffer.Buffer,
 
 }
 
+// fetchNode. fetch node page & catat di needToUnpin. dicatat sekali per fetch biar jumlah unpin sama dengan pin count.
+func (rt *Rtreed) fetchNode(pageNum types.BlockNum, needToUnpin *[]unpinPage) (*tree.Node, *buffer.Buffer, error) {
+	node, buffer, err := rt.getNodeAndPage(pageNum)
+	if err != nil {
+		return nil, nil, err
+	}
+	*needToUnpin = append(*needToUnpin, newUnpinPage(pageNum, false))
+	return node, buffer, nil
+}
+
+// setParent. update parent pointer dari node child.
+func (rt *Rtreed) setParent(child, parent types.BlockNum, needToUnpin *[]unpinPage) error {
+	childNode, childPage, err := rt.fetchNode(child, needToUnpin)
+	if err != nil {
+		return err
+	}
+	childNode.SetParent(parent)
+	childPage.SerializeNode(childNode)
+	markDirty(needToUnpin, child)
+	return nil
+}
+
+// markDirty. tandai page yang sudah di-fetch sebagai dirty, biar diflush ke disk sebelum di evict dari buffer pool.
+func markDirty(needToUnpin *[]unpinPage, pageNum types.BlockNum) {
+	for i := range *needToUnpin {
+		if (*needToUnpin)[i].pageNum == pageNum {
+			(*needToUnpin)[i].isDirty = true
+		}
+	}
+}
+
+func (rt *Rtreed) unpin(pageNum types.BlockNum, isDirty bool) {
+	blockId := disk.NewBlockID(rt.pageFileName, int(pageNum))
+	rt.bufferPoolManager.UnpinPage(blockId, isDirty)
+}
+
+func (rt *Rtreed) unpinAll(needToUnpin []unpinPage) {
+	for _, p := range needToUnpin {
+		rt.unpin(p.getPageNum(), p.getIsDirty())
+	}
+}
+
 func (rt *Rtreed) writeMeta() error {
-	page := disk.NewPage(lib.MAX_PAGE_SIZE)
-	blockId := disk.NewBlockID(lib.PAGE_FILE_NAME, metaPageNum)
+	page := disk.NewPage(rt.pageSize)
+	blockId := disk.NewBlockID(rt.pageFileName, metaPageNum)
 
 	page.SerializeMetadata(rt.metadata)
 	err := rt.diskManager.Write(blockId, page)
 	return err
 }
 
+// readStoredMeta. read meta page sebelum page size tree diketahui. meta page selalu ada di block 0 & muat di page size terkecil.
+func readStoredMeta(dir, pageFileName string) (*meta.Meta, error) {
+	dm := disk.NewDiskManager(dir, lib.PAGE_SIZE_ARRAY[0])
+	defer dm.Close()
+
+	page := disk.NewPage(lib.PAGE_SIZE_ARRAY[0])
+	err := dm.Read(disk.NewBlockID(pageFileName, metaPageNum), page)
+	if err != nil {
+		return nil, err
+	}
+	return page.DeserializeMetadata(), nil
+}
+
 func (rt *Rtreed) readMeta() (*meta.Meta, error) {
-	blockId := disk.NewBlockID(lib.PAGE_FILE_NAME, metaPageNum)
+	blockId := disk.NewBlockID(rt.pageFileName, metaPageNum)
 	buffer, err := rt.bufferPoolManager.FetchPage(blockId)
 	if err != nil {
 		return nil, err
 	}
 
 	metadata := buffer.DeserializeMetadata()
+	rt.bufferPoolManager.UnpinPage(blockId, false)
 	return metadata, nil
 }
 func (rt *Rtreed) updateMetaHeightSeize(height int, size int32) {
@@ -159,12 +188,6 @@ func (d *Rtreed) Close() error {
 	if err != nil {
 		return err
 	}
-	root, err := d.getNode(d.metadata.GetRoot())
-	if err != nil {
-		return err
-	}
-	_ = root
-
 	err = d.bufferPoolManager.FlushAll()
 	if err != nil {
 		return err
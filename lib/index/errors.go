@@ -0,0 +1,44 @@
+package index
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/lintang-b-s/rtreed/lib/buffer"
+	"github.com/lintang-b-s/rtreed/lib/disk"
+)
+
+var (
+	ErrInvalidOptions     = errors.New("invalid rtreed options")
+	ErrPageSizeTooSmall   = errors.New("page size too small for max entries")
+	ErrInvalidMagic       = errors.New("not an rtreed page file")
+	ErrUnsupportedVersion = errors.New("unsupported rtreed page format version")
+	ErrObjectNotFound     = errors.New("object not found")
+	ErrCorruptTree        = errors.New("node is not referenced by its parent")
+	ErrCorruptIDIndex     = errors.New("id index points to a leaf without the object")
+	ErrInvalidSplit       = errors.New("splitter must return every entry in two groups of at least min entries")
+	ErrNotEmpty           = errors.New("bulk load needs an empty tree")
+	ErrInvalidGrid        = errors.New("grid must have at least one cell in each direction")
+	ErrInvalidTile        = errors.New("tile coordinates out of range")
+	ErrDimMismatch        = errors.New("object or tree has a different number of dimensions")
+	ErrNotGeographic      = errors.New("query needs a 2 dimensional lat/lon tree")
+	ErrTimeRange          = errors.New("objects of a temporal tree, and only those, need a time range that does not end before it starts")
+	ErrPayloadTooLarge    = errors.New("object data is larger than the max spatial data size")
+	ErrEmptyGeometry      = errors.New("object has an empty extent, e.g. a line without points")
+
+	// errors from the storage layers, re-exported so callers only need this package to match on them.
+	ErrNoAvailableFrame = buffer.ErrNoAvailableFrame
+	ErrAllPagesPinned   = buffer.ErrAllPagesPinned
+	ErrBlockOutOfRange  = disk.ErrBlockOutOfRange
+)
+
+// ConfigMismatchError is returned by Open when a non-zero option differs from the configuration stored in the meta page.
+type ConfigMismatchError struct {
+	Field  string
+	Stored int
+	Given  int
+}
+
+func (e *ConfigMismatchError) Error() string {
+	return fmt.Sprintf("rtreed: %s mismatch: stored %d, given %d", e.Field, e.Stored, e.Given)
+}
@@ -0,0 +1,259 @@
+package index
+
+import (
+	"bufio"
+	"container/heap"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+
+	"github.com/lintang-b-s/rtreed/lib/disk"
+	"github.com/lintang-b-s/rtreed/lib/tree"
+)
+
+// external merge sort leaf entry berdasarkan hilbert key, untuk bulk load data yang tidak muat di memory. entry
+// di-encode jadi record key (16) | len (4) | page leaf berisi entry tsb (len byte), jadi semua jenis object ikut
+// format page. record ditampung di memory sampai budget penuh, diurutkan & ditulis jadi run file di dir db, lalu
+// semua run di-merge k-way. kalau buffer baca semua run tidak muat di budget, run di-merge bertahap.
+
+const (
+	runRecordHeaderSize = 16 + 4
+	// runBufferSize. buffer baca/tulis tiap run file.
+	runBufferSize = 64 * 1024
+	// sortRecordOverhead. memory per record di luar byte nya (sortRecord di slice records).
+	sortRecordOverhead = 32
+)
+
+// sortRecord. record di arena externalSorter.
+type sortRecord struct {
+	key       tree.HilbertKey
+	off, size int
+}
+
+type externalSorter struct {
+	dir    string
+	prefix string
+	budget int
+
+	arena   []byte
+	records []sortRecord
+	runs    []string // run yang belum di-merge
+	created []string // semua run file, dihapus di cleanup
+}
+
+func newExternalSorter(dir, prefix string, budget int) *externalSorter {
+	return &externalSorter{dir: dir, prefix: prefix, budget: budget}
+}
+
+// add. encode e & tampung di memory. arena & records tumbuh dengan append (bisa sampai 2x isinya), jadi di-spill
+// ke run file begitu isinya setengah budget.
+func (s *externalSorter) add(e *tree.Entry) error {
+	node := tree.NewNode([]*tree.Entry{e}, 0, 1, true)
+	obj := e.GetObject()
+	// +1: SerializeNode tidak memakai byte terakhir page
+	page := disk.NewPage(disk.NodePageSize(e.GetRect().Dims(), 1, len(obj.Data())) + 1)
+	page.SerializeNode(node)
+
+	s.records = append(s.records, sortRecord{key: e.GetLHV(), off: len(s.arena),
+		size: len(page.Contents())})
+	s.arena = append(s.arena, page.Contents()...)
+	if len(s.arena)+len(s.records)*sortRecordOverhead < s.budget/2 {
+		return nil
+	}
+	return s.spill()
+}
+
+// spill. urutkan record di memory & tulis jadi run file baru.
+func (s *externalSorter) spill() error {
+	sort.SliceStable(s.records, func(i, j int) bool { return s.records[i].key.Less(s.records[j].key) })
+
+	w, err := s.createRun()
+	if err != nil {
+		return err
+	}
+	for _, rec := range s.records {
+		if err := w.write(rec.key, s.arena[rec.off:rec.off+rec.size]); err != nil {
+			w.close()
+			return err
+		}
+	}
+	if err := w.close(); err != nil {
+		return err
+	}
+
+	s.arena, s.records = s.arena[:0], s.records[:0]
+	return nil
+}
+
+// sorted. panggil emit untuk semua entry urut hilbert key.
+func (s *externalSorter) sorted(emit func(e *tree.Entry) error) error {
+	decode := func(_ tree.HilbertKey, rec []byte) error {
+		node := disk.NewPageFromByteSlice(rec).DeserializeNode()
+		return emit(node.GetEntries()[0])
+	}
+
+	if len(s.runs) == 0 {
+		sort.SliceStable(s.records, func(i, j int) bool { return s.records[i].key.Less(s.records[j].key) })
+		for _, rec := range s.records {
+			if err := decode(rec.key, s.arena[rec.off:rec.off+rec.size]); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+
+	if len(s.records) > 0 {
+		if err := s.spill(); err != nil {
+			return err
+		}
+	}
+	s.arena, s.records = nil, nil
+
+	// satu buffer untuk tiap run yang dibaca & satu untuk run hasil merge
+	fanIn := max(2, s.budget/runBufferSize-1)
+	for len(s.runs) > fanIn {
+		w, err := s.createRun()
+		if err != nil {
+			return err
+		}
+		err = mergeRuns(s.runs[:fanIn], w.write)
+		if cerr := w.close(); err == nil {
+			err = cerr
+		}
+		if err != nil {
+			return err
+		}
+		for _, name := range s.runs[:fanIn] {
+			if err := os.Remove(name); err != nil {
+				return err
+			}
+		}
+		// run hasil merge ada di belakang runs, jadi tiap record di-merge ulang sesedikit mungkin
+		s.runs = s.runs[fanIn:]
+	}
+	return mergeRuns(s.runs, decode)
+}
+
+// createRun. buat run file baru di dir db, tercatat di runs.
+func (s *externalSorter) createRun() (*runWriter, error) {
+	name := filepath.Join(s.dir, fmt.Sprintf("%s.run%d", s.prefix, len(s.created)))
+	f, err := os.Create(name)
+	if err != nil {
+		return nil, err
+	}
+	s.created = append(s.created, name)
+	s.runs = append(s.runs, name)
+	return &runWriter{f: f, w: bufio.NewWriterSize(f, runBufferSize)}, nil
+}
+
+// cleanup. hapus semua run file.
+func (s *externalSorter) cleanup() error {
+	var firstErr error
+	for _, name := range s.created {
+		if err := os.Remove(name); err != nil && !os.IsNotExist(err) && firstErr == nil {
+			firstErr = err
+		}
+	}
+	s.created, s.runs = nil, nil
+	return firstErr
+}
+
+type runWriter struct {
+	f   *os.File
+	w   *bufio.Writer
+	hdr [runRecordHeaderSize]byte
+}
+
+func (rw *runWriter) write(key tree.HilbertKey, rec []byte) error {
+	binary.LittleEndian.PutUint64(rw.hdr[0:], key.Hi)
+	binary.LittleEndian.PutUint64(rw.hdr[8:], key.Lo)
+	binary.LittleEndian.PutUint32(rw.hdr[16:], uint32(len(rec)))
+	if _, err := rw.w.Write(rw.hdr[:]); err != nil {
+		return err
+	}
+	_, err := rw.w.Write(rec)
+	return err
+}
+
+func (rw *runWriter) close() error {
+	err := rw.w.Flush()
+	if cerr := rw.f.Close(); err == nil {
+		err = cerr
+	}
+	return err
+}
+
+// runReader. record terdepan run file yang belum di-emit.
+type runReader struct {
+	f   *os.File
+	r   *bufio.Reader
+	key tree.HilbertKey
+	rec []byte
+	hdr [runRecordHeaderSize]byte
+}
+
+// next. baca record berikutnya, false kalau run sudah habis.
+func (rr *runReader) next() (bool, error) {
+	if _, err := io.ReadFull(rr.r, rr.hdr[:]); err == io.EOF {
+		return false, nil
+	} else if err != nil {
+		return false, err
+	}
+	rr.key = tree.HilbertKey{Hi: binary.LittleEndian.Uint64(rr.hdr[0:]), Lo: binary.LittleEndian.Uint64(rr.hdr[8:])}
+	size := int(binary.LittleEndian.Uint32(rr.hdr[16:]))
+	if cap(rr.rec) < size {
+		rr.rec = make([]byte, size)
+	}
+	rr.rec = rr.rec[:size]
+	_, err := io.ReadFull(rr.r, rr.rec)
+	return err == nil, err
+}
+
+// mergeRuns. k-way merge run files, emit dipanggil urut key. rec cuma valid selama emit.
+func mergeRuns(runs []string, emit func(key tree.HilbertKey, rec []byte) error) error {
+	queue := make(runQueue, 0, len(runs))
+	defer func() {
+		for _, rr := range queue {
+			rr.f.Close()
+		}
+	}()
+
+	for _, name := range runs {
+		f, err := os.Open(name)
+		if err != nil {
+			return err
+		}
+		rr := &runReader{f: f, r: bufio.NewReaderSize(f, runBufferSize)}
+		ok, err := rr.next()
+		if err != nil || !ok {
+			f.Close()
+			if err != nil {
+				return err
+			}
+			continue
+		}
+		queue = append(queue, rr)
+	}
+	heap.Init(&queue)
+
+	for queue.Len() > 0 {
+		rr := queue[0]
+		if err := emit(rr.key, rr.rec); err != nil {
+			return err
+		}
+		ok, err := rr.next()
+		if err != nil {
+			return err
+		}
+		if ok {
+			heap.Fix(&queue, 0)
+		} else {
+			heap.Pop(&queue)
+			rr.f.Close()
+		}
+	}
+	return nil
+}
@@ -1,6 +1,10 @@
 package index
 
-import "math"
+import (
+	"math"
+
+	"github.com/lintang-b-s/rtreed/lib/tree"
+)
 
 // https://www.movable-type.co.uk/scripts/latlong.html
 
@@ -17,6 +21,10 @@ func degreeToRadians(angle float64) float64 {
 	return angle * (math.Pi / 180.0)
 }
 
+func radiansToDegree(angle float64) float64 {
+	return angle * (180.0 / math.Pi)
+}
+
 func haversineDistance(latOne, longOne, latTwo, longTwo float64) float64 {
 	latOne = degreeToRadians(latOne)
 	longOne = degreeToRadians(longOne)
@@ -32,30 +40,193 @@ func HaversineDistance(latOne, longOne, latTwo, longTwo float64) float64 {
 	return haversineDistance(latOne, longOne, latTwo, longTwo)
 }
 
-// GetDestinationPoint returns the destination point given the starting point, bearing and distance
-// dist in km
-func getDestinationPoint(lat1, lon1 float64, bearing float64, dist float64) (float64, float64) {
+// expandRect returns the lat/lon rectangles covering every point within distance km of r.
+// the latitude extent comes from the points due north and south (bearing 0/180). the points due east and west
+// (bearing 90/270) are not the widest points of a circle away from the equator, so the longitude extent uses
+// the meridians tangent to the circle instead, at r's highest absolute latitude where the margin is widest.
+// a rect with a pole within distance covers every longitude. r's longitudes may go past ±180, the result is
+// split at the antimeridian.
+func expandRect(r tree.Rect, distance float64) []tree.Rect {
+	dr := distance / earthRadiusKM // angular radius
+
+	minLat := degreeToRadians(r.GetSLat()) - dr
+	maxLat := degreeToRadians(r.GetTLat()) + dr
+	minLon := degreeToRadians(r.GetSLon())
+	maxLon := degreeToRadians(r.GetTLon())
+
+	if minLat > -math.Pi/2 && maxLat < math.Pi/2 {
+		maxAbsLat := degreeToRadians(math.Max(math.Abs(r.GetSLat()), math.Abs(r.GetTLat())))
+		deltaLon := math.Asin(math.Sin(dr) / math.Cos(maxAbsLat))
+		minLon -= deltaLon
+		maxLon += deltaLon
+	} else {
+		// pole within distance
+		minLat = math.Max(minLat, -math.Pi/2)
+		maxLat = math.Min(maxLat, math.Pi/2)
+		minLon = -math.Pi
+		maxLon = math.Pi
+	}
+
+	return splitAntimeridian(radiansToDegree(minLat), radiansToDegree(minLon), radiansToDegree(maxLat), radiansToDegree(maxLon))
+}
+
+// splitAntimeridian. rectangle yang longitudenya keluar dari [-180, 180] dipecah jadi dua di antimeridian.
+func splitAntimeridian(minLatDeg, minLonDeg, maxLatDeg, maxLonDeg float64) []tree.Rect {
+	if maxLonDeg-minLonDeg >= 360 {
+		return []tree.Rect{tree.NewRectFromBounds(minLatDeg, -180, maxLatDeg, 180)}
+	}
+	if minLonDeg < -180 {
+		return []tree.Rect{
+			tree.NewRectFromBounds(minLatDeg, minLonDeg+360, maxLatDeg, 180),
+			tree.NewRectFromBounds(minLatDeg, -180, maxLatDeg, maxLonDeg),
+		}
+	}
+	if maxLonDeg > 180 {
+		return []tree.Rect{
+			tree.NewRectFromBounds(minLatDeg, minLonDeg, maxLatDeg, 180),
+			tree.NewRectFromBounds(minLatDeg, -180, maxLatDeg, maxLonDeg-360),
+		}
+	}
+	return []tree.Rect{tree.NewRectFromBounds(minLatDeg, minLonDeg, maxLatDeg, maxLonDeg)}
+}
 
-	dr := dist / earthRadiusKM
+// pointRectDistance returns the great-circle distance in km from p to the closest point of r, 0 if p is inside r.
+// it is a lower bound of the distance from p to every object inside r.
+//
+// Schubert, Zimek & Kriegel, "Geodetic Distance Queries on R-Trees for Indexing Geographic Data" (SSTD 2013).
+func pointRectDistance(p tree.Point, r tree.Rect) float64 {
+	if p.Lon >= r.GetSLon() && p.Lon <= r.GetTLon() {
+		// closest point is on p's meridian, the latitude gap is the distance.
+		switch {
+		case p.Lat < r.GetSLat():
+			return degreeToRadians(r.GetSLat()-p.Lat) * earthRadiusKM
+		case p.Lat > r.GetTLat():
+			return degreeToRadians(p.Lat-r.GetTLat()) * earthRadiusKM
+		}
+		return 0
+	}
+
+	// otherwise the closest point is on one of the two meridian edges.
+	return math.Min(pointMeridianDistance(p, r.GetSLon(), r.GetSLat(), r.GetTLat()),
+		pointMeridianDistance(p, r.GetTLon(), r.GetSLat(), r.GetTLat()))
+}
 
-	bearing = (bearing * (math.Pi / 180.0))
+// pointMeridianDistance. jarak p ke segment meridian lon antara latitude sLat..tLat. jarak sepanjang meridian cuma
+// punya satu minimum (di kaki tegak lurus dari p), jadi kalau kakinya di luar segment yang terdekat salah satu ujungnya.
+func pointMeridianDistance(p tree.Point, lon, sLat, tLat float64) float64 {
+	dLon := math.Abs(degreeToRadians(p.Lon - lon))
+	if dLon > math.Pi {
+		dLon = 2*math.Pi - dLon
+	}
+
+	if dLon < math.Pi/2 {
+		latRad := degreeToRadians(p.Lat)
+		footLat := radiansToDegree(math.Atan(math.Tan(latRad) / math.Cos(dLon)))
+		if footLat >= sLat && footLat <= tLat {
+			// cross track distance ke meridian
+			return math.Asin(math.Cos(latRad)*math.Sin(dLon)) * earthRadiusKM
+		}
+	}
+
+	return math.Min(haversineDistance(p.Lat, p.Lon, sLat, lon), haversineDistance(p.Lat, p.Lon, tLat, lon))
+}
 
-	lat1 = (lat1 * (math.Pi / 180.0))
-	lon1 = (lon1 * (math.Pi / 180.0))
+// initialBearing. bearing awal (radian, searah jarum jam dari utara) great circle dari a ke b.
+func initialBearing(a, b tree.Point) float64 {
+	latA, latB := degreeToRadians(a.Lat), degreeToRadians(b.Lat)
+	dLon := degreeToRadians(b.Lon - a.Lon)
 
-	lat2Part1 := math.Sin(lat1) * math.Cos(dr)
-	lat2Part2 := math.Cos(lat1) * math.Sin(dr) * math.Cos(bearing)
+	y := math.Sin(dLon) * math.Cos(latB)
+	x := math.Cos(latA)*math.Sin(latB) - math.Sin(latA)*math.Cos(latB)*math.Cos(dLon)
+	return math.Atan2(y, x)
+}
 
-	lat2 := math.Asin(lat2Part1 + lat2Part2)
+// pointSegmentDistance returns the great-circle distance in km from p to the closest point of the great-circle
+// segment a-b. it is the cross-track distance when the foot of the perpendicular from p lies on the segment
+// (along-track distance between 0 and the segment length), otherwise the distance to the nearer endpoint.
+func pointSegmentDistance(p, a, b tree.Point) float64 {
+	dAP := haversineDistance(a.Lat, a.Lon, p.Lat, p.Lon) / earthRadiusKM
+	dAB := haversineDistance(a.Lat, a.Lon, b.Lat, b.Lon) / earthRadiusKM
+	if dAB == 0 || dAP == 0 {
+		return dAP * earthRadiusKM
+	}
+
+	dBearing := initialBearing(a, p) - initialBearing(a, b)
+	crossTrack := math.Asin(math.Sin(dAP) * math.Sin(dBearing))
+	alongTrack := math.Acos(math.Max(-1, math.Min(1, math.Cos(dAP)/math.Cos(crossTrack))))
+
+	if math.Cos(dBearing) < 0 {
+		// foot of the perpendicular is behind a
+		return dAP * earthRadiusKM
+	}
+	if alongTrack > dAB {
+		return haversineDistance(b.Lat, b.Lon, p.Lat, p.Lon)
+	}
+	return math.Abs(crossTrack) * earthRadiusKM
+}
 
-	lon2Part1 := math.Sin(bearing) * math.Sin(dr) * math.Cos(lat1)
-	lon2Part2 := math.Cos(dr) - (math.Sin(lat1) * math.Sin(lat2))
+// segmentBounds returns the lat/lon rectangles covering every point within distance km of the great-circle
+// segment a-b. a great circle's highest latitude is at its vertex (Clairaut: cos lat * sin bearing is constant
+// along it), so when the segment passes its vertex the vertex latitude is the extent instead of the endpoints.
+func segmentBounds(a, b tree.Point, distance float64) []tree.Rect {
+	minLat := degreeToRadians(math.Min(a.Lat, b.Lat))
+	maxLat := degreeToRadians(math.Max(a.Lat, b.Lat))
+	if a != b {
+		bearingAB, bearingBA := initialBearing(a, b), initialBearing(b, a)
+		vertexLat := math.Acos(math.Abs(math.Sin(bearingAB) * math.Cos(degreeToRadians(a.Lat))))
+		switch {
+		case math.Cos(bearingAB) > 0 && math.Cos(bearingBA) > 0:
+			// both endpoints head north towards each other, the northern vertex is between them
+			maxLat = vertexLat
+		case math.Cos(bearingAB) < 0 && math.Cos(bearingBA) < 0:
+			minLat = -vertexLat
+		}
+	}
+
+	lonA := degreeToRadians(a.Lon)
+	dLon := math.Remainder(degreeToRadians(b.Lon)-lonA, 2*math.Pi)
+	minLon, maxLon := math.Min(lonA, lonA+dLon), math.Max(lonA, lonA+dLon)
+
+	arc := tree.NewRectFromBounds(radiansToDegree(minLat), radiansToDegree(minLon), radiansToDegree(maxLat), radiansToDegree(maxLon))
+	return expandRect(arc, distance)
+}
 
-	lon2 := lon1 + math.Atan2(lon2Part1, lon2Part2)
-	lon2 = math.Mod((lon2+3*math.Pi), (2*math.Pi)) - math.Pi
+// rectRectDistance returns a lower bound in km of the great-circle distance between any point of r1 and any point
+// of r2, 0 if they overlap. it is the larger of two bounds:
+//   - the latitude gap, two points can't be closer than their latitude difference.
+//   - when the longitude ranges are disjoint, every path from r1 to r2 crosses one of r2's meridian edges, and
+//     the distance from a point at lat φ to the great circle of a meridian Δλ away is asin(cos φ |sin Δλ|).
+func rectRectDistance(r1, r2 tree.Rect) float64 {
+	latGap := math.Max(r2.GetSLat()-r1.GetTLat(), r1.GetSLat()-r2.GetTLat())
+	latBound := math.Max(0, degreeToRadians(latGap))
+
+	lonBound := 0.0
+	if !lonRangesOverlap(r1, r2) {
+		maxAbsLat := degreeToRadians(math.Max(math.Abs(r1.GetSLat()), math.Abs(r1.GetTLat())))
+		minSin := math.Min(minAbsSinLonDiff(r1, r2.GetSLon()), minAbsSinLonDiff(r1, r2.GetTLon()))
+		lonBound = math.Asin(math.Cos(maxAbsLat) * minSin)
+	}
+
+	return math.Max(latBound, lonBound) * earthRadiusKM
+}
 
-	lat2 = lat2 * (180.0 / math.Pi)
-	lon2 = lon2 * (180.0 / math.Pi)
+// lonRangesOverlap. cek overlap range longitude r1 dan r2, termasuk yang bersebelahan lewat antimeridian.
+func lonRangesOverlap(r1, r2 tree.Rect) bool {
+	for _, shift := range []float64{-360, 0, 360} {
+		if r1.GetSLon() <= r2.GetTLon()+shift && r2.GetSLon()+shift <= r1.GetTLon() {
+			return true
+		}
+	}
+	return false
+}
 
-	return lat2, lon2
+// minAbsSinLonDiff. min |sin(λ - lon)| untuk λ di range longitude r. |sin| concave di antara kelipatan π, jadi
+// minimumnya di ujung range kecuali range nya memuat kelipatan π (lon atau antimeridian nya).
+func minAbsSinLonDiff(r tree.Rect, lon float64) float64 {
+	lo := degreeToRadians(r.GetSLon() - lon)
+	hi := degreeToRadians(r.GetTLon() - lon)
+	if math.Floor(lo/math.Pi) != math.Floor(hi/math.Pi) || math.Remainder(lo, math.Pi) == 0 {
+		return 0
+	}
+	return math.Min(math.Abs(math.Sin(lo)), math.Abs(math.Sin(hi)))
 }
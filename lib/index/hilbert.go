@@ -0,0 +1,175 @@
+package index
+
+import (
+	"sort"
+
+	"github.com/lintang-b-s/rtreed/lib/buffer"
+	"github.com/lintang-b-s/rtreed/lib/tree"
+	"github.com/lintang-b-s/rtreed/types"
+)
+
+// Hilbert R-tree, Kamel & Faloutsos 1994. tiap entry menyimpan LHV (largest hilbert value) subtree nya, dihitung
+// ulang di parentEntryFor & updateParentEntry untuk semua strategy, jadi tree bisa dibuka ulang dengan strategy lain.
+
+// chooseByLHV. child entry dengan LHV terkecil yang >= h, kalau tidak ada yang LHV nya paling besar.
+func chooseByLHV(entries []*tree.Entry, h tree.HilbertKey) types.BlockNum {
+	var above, largest *tree.Entry
+	for _, en := range entries {
+		lhv := en.GetLHV()
+		if !lhv.Less(h) && (above == nil || lhv.Less(above.GetLHV())) {
+			above = en
+		}
+		if largest == nil || largest.GetLHV().Less(lhv) {
+			largest = en
+		}
+	}
+
+	if above != nil {
+		return above.GetChild()
+	}
+	if largest != nil {
+		return largest.GetChild()
+	}
+	return 0
+}
+
+// largestLHV. LHV terbesar di entries.
+func largestLHV(entries []*tree.Entry) tree.HilbertKey {
+	var lhv tree.HilbertKey
+	for _, e := range entries {
+		if lhv.Less(e.GetLHV()) {
+			lhv = e.GetLHV()
+		}
+	}
+	return lhv
+}
+
+// sortByLHV. copy entries urut LHV.
+func sortByLHV(entries []*tree.Entry) []*tree.Entry {
+	sorted := append([]*tree.Entry(nil), entries...)
+	sort.SliceStable(sorted, func(i, j int) bool { return sorted[i].GetLHV().Less(sorted[j].GetLHV()) })
+	return sorted
+}
+
+// cooperatingSibling. index entry tetangga entries[idx] di urutan LHV, yang subtree nya lebih sedikit object kalau
+// ada dua. before true kalau LHV tetangga nya lebih kecil. -1 kalau entries[idx] satu-satunya entry.
+func cooperatingSibling(entries []*tree.Entry, idx int) (sibling int, before bool) {
+	order := make([]int, len(entries))
+	for i := range order {
+		order[i] = i
+	}
+	sort.SliceStable(order, func(i, j int) bool { return entries[order[i]].GetLHV().Less(entries[order[j]].GetLHV()) })
+
+	pos := 0
+	for order[pos] != idx {
+		pos++
+	}
+	sibling = -1
+	if pos > 0 {
+		sibling, before = order[pos-1], true
+	}
+	if pos+1 < len(order) && (sibling < 0 || entries[order[pos+1]].GetCount() < entries[sibling].GetCount()) {
+		sibling, before = order[pos+1], false
+	}
+	return sibling, before
+}
+
+// deferredSplit. overflow node n (bukan root) di StrategyHilbert: entry n & cooperating sibling nya dibagi urut
+// hilbert value (lihat groupSizes), node dengan LHV lebih kecil dapat bagian depan. kalau keduanya penuh dibuat node
+// baru & entry nya dibagi ke 3 node (2-to-3 split). entry sibling di parent diupdate di sini, entry n & node baru
+// oleh adjustTree. return page n & page node baru, nil kalau cukup redistribusi.
+func (rt *Rtreed) deferredSplit(nPage *buffer.Buffer, n *tree.Node,
+	needToUnpin *[]unpinPage) (*buffer.Buffer, *buffer.Buffer, error) {
+	parent, parentPage, err := rt.fetchNode(n.GetParent(), needToUnpin)
+	if err != nil {
+		return nil, nil, err
+	}
+	idx := entryIndexOf(parent, n.GetPageNum())
+	if idx < 0 {
+		return nil, nil, ErrCorruptTree
+	}
+	sibIdx, before := cooperatingSibling(parent.GetEntries(), idx)
+	if sibIdx < 0 {
+		return rt.splitNode(nPage, rt.minEntries, needToUnpin)
+	}
+	sib, sibPage, err := rt.fetchNode(parent.GetEntry(sibIdx).GetChild(), needToUnpin)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	// page asal tiap entry, cuma entry yang pindah page yang parent pointer / id index nya diupdate
+	from := make(map[*tree.Entry]types.BlockNum, n.GetEntriesSize()+sib.GetEntriesSize())
+	for _, e := range n.GetEntries() {
+		from[e] = n.GetPageNum()
+	}
+	for _, e := range sib.GetEntries() {
+		from[e] = sib.GetPageNum()
+	}
+	entries := sortByLHV(append(append([]*tree.Entry(nil), n.GetEntries()...), sib.GetEntries()...))
+
+	nodes, pages := []*tree.Node{n, sib}, []*buffer.Buffer{nPage, sibPage}
+	if before {
+		nodes[0], nodes[1], pages[0], pages[1] = sib, n, sibPage, nPage
+	}
+	groups := 2
+	if len(entries) > 2*rt.maxEntries {
+		groups = 3
+	}
+	last := parent.GetEntry(idx).GetLHV() == largestLHV(parent.GetEntries())
+	sizes := groupSizes(len(entries), groups, rt.minEntries, rt.maxEntries, last)
+	for i := 0; i < 2; i++ {
+		nodes[i].SetEntries(entries[:sizes[i]:sizes[i]])
+		entries = entries[sizes[i]:]
+	}
+
+	var newPage *buffer.Buffer
+	if groups == 3 {
+		newNode := tree.NewNode(entries, n.GetParent(), n.Level(), n.IsLeaf())
+		newNode, newPage, err = rt.writeNodeAndGetPage(newNode, needToUnpin)
+		if err != nil {
+			return nil, nil, err
+		}
+		nodes, pages = append(nodes, newNode), append(pages, newPage)
+	}
+
+	for i, node := range nodes {
+		for _, e := range node.GetEntries() {
+			if from[e] == node.GetPageNum() {
+				continue
+			}
+			if node.IsLeaf() {
+				obj := e.GetObject()
+				err = rt.setIDPage(obj.ID(), node.GetPageNum())
+			} else {
+				err = rt.setParent(e.GetChild(), node.GetPageNum(), needToUnpin)
+			}
+			if err != nil {
+				return nil, nil, err
+			}
+		}
+		pages[i].SerializeNode(node)
+		markDirty(needToUnpin, node.GetPageNum())
+	}
+
+	updateParentEntry(parent.GetEntry(sibIdx), sib)
+	parentPage.SerializeNode(parent)
+	markDirty(needToUnpin, parent.GetPageNum())
+	return nPage, newPage, nil
+}
+
+// groupSizes. jumlah entry tiap node hasil deferredSplit. biasanya dibagi rata. kalau node yang overflow adalah
+// child terakhir parent nya (insert yang hilbert value nya terus naik selalu masuk ke situ), node depan diisi penuh &
+// node belakang cukup minEntries, jadi utilisasi node tetap ~100% untuk workload append.
+func groupSizes(total, groups, minEntries, maxEntries int, last bool) []int {
+	sizes := make([]int, groups)
+	for i := range sizes {
+		rest := groups - i
+		if last {
+			sizes[i] = min(maxEntries, total-minEntries*(rest-1))
+		} else {
+			sizes[i] = total / rest
+		}
+		total -= sizes[i]
+	}
+	return sizes
+}
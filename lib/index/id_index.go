@@ -0,0 +1,80 @@
+package index
+
+import (
+	"github.com/lintang-b-s/rtreed/lib/disk"
+	"github.com/lintang-b-s/rtreed/lib/tree"
+	"github.com/lintang-b-s/rtreed/types"
+)
+
+// id index. file page terpisah (Options.IDFileName) berisi array slot 8 byte, slot ke-id berisi page num leaf yang
+// menyimpan object dengan id tsb (0 kalau id tidak ada). page nya dibaca & ditulis lewat buffer pool yang sama dengan
+// node page. leaf entry cuma pindah page saat diinsert ke leaf & saat leaf di-split, jadi slot cukup diupdate di situ.
+
+const idSlotSize = 8
+
+// idSlot. block & offset slot id.
+func (rt *Rtreed) idSlot(id uint64) (disk.BlockID, int32) {
+	perPage := uint64(rt.pageSize / idSlotSize)
+	return disk.NewBlockID(rt.idFileName, int(id/perPage)), int32(id%perPage) * idSlotSize
+}
+
+// lookupID. page leaf object dengan id, 0 kalau id tidak ada.
+func (rt *Rtreed) lookupID(id uint64) (types.BlockNum, error) {
+	blockID, offset := rt.idSlot(id)
+	if id == 0 || blockID.GetBlockNum() >= rt.idBlocks {
+		return 0, nil
+	}
+
+	buffer, err := rt.bufferPoolManager.FetchPage(blockID)
+	if err != nil {
+		return 0, err
+	}
+	pageNum := types.BlockNum(buffer.GetContents().GetUint64(offset))
+	rt.bufferPoolManager.UnpinPage(blockID, false)
+	return pageNum, nil
+}
+
+// setIDPage. catat page leaf object dengan id, pageNum 0 menghapus id dari index. file diperpanjang dengan page
+// kosong sampai slot id muat.
+func (rt *Rtreed) setIDPage(id uint64, pageNum types.BlockNum) error {
+	if id == 0 {
+		return nil
+	}
+	blockID, offset := rt.idSlot(id)
+	for rt.idBlocks <= blockID.GetBlockNum() {
+		if _, err := rt.diskManager.Append(rt.idFileName); err != nil {
+			return err
+		}
+		rt.idBlocks++
+	}
+
+	buffer, err := rt.bufferPoolManager.FetchPage(blockID)
+	if err != nil {
+		return err
+	}
+	buffer.GetContents().PutUint64(offset, uint64(pageNum))
+	rt.bufferPoolManager.UnpinPage(blockID, true)
+	return nil
+}
+
+// setLeafIDs. catat pageNum sebagai page leaf semua object di entries.
+func (rt *Rtreed) setLeafIDs(entries []*tree.Entry, pageNum types.BlockNum) error {
+	for _, e := range entries {
+		obj := e.GetObject()
+		if err := rt.setIDPage(obj.ID(), pageNum); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// entryIndexByID. index entry leaf n dengan object id, -1 kalau tidak ada.
+func entryIndexByID(n *tree.Node, id uint64) int {
+	for i, e := range n.GetEntries() {
+		obj := e.GetObject()
+		if obj.ID() == id {
+			return i
+		}
+	}
+	return -1
+}
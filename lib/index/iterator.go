@@ -0,0 +1,119 @@
+package index
+
+import (
+	"iter"
+
+	"github.com/lintang-b-s/rtreed/lib/disk"
+	"github.com/lintang-b-s/rtreed/lib/tree"
+	"github.com/lintang-b-s/rtreed/types"
+)
+
+// SearchIter is the streaming version of Search. the tree is traversed lazily while the caller ranges over the
+// sequence, only the page being read is pinned and it is unpinned when the caller breaks out of the loop.
+// with WithZeroCopy a payload is only valid until the next iteration. WithSortByDistance is ignored.
+func (rt *Rtreed) SearchIter(rect tree.Rect, pred Predicate, opts ...SearchOption) iter.Seq2[tree.SpatialData, error] {
+	if rect.Dims() != rt.dim {
+		return errIter(ErrDimMismatch)
+	}
+	cfg := newSearchConfig(opts)
+	return rt.searchIter([]entryFilter{pred.filter(rect)}, cfg, pred.accept(rect))
+}
+
+// SearchWithinRadiusIter is the streaming version of SearchWithinRadius, see SearchIter. objects are yielded in
+// tree order, not by distance.
+func (rt *Rtreed) SearchWithinRadiusIter(p tree.Point, radius float64, opts ...SearchOption) iter.Seq2[tree.SpatialData, error] {
+	if p.Dims() != rt.dim {
+		return errIter(ErrDimMismatch)
+	}
+	cfg := newSearchConfig(opts)
+
+	bounds := rt.metric.expand(p.ToRect(0), radius)
+	filters := make([]entryFilter, len(bounds))
+	for i, bound := range bounds {
+		filters[i] = overlapFilter(bound)
+	}
+	return rt.searchIter(filters, cfg, radiusAccept(rt.metric, p, radius, cfg))
+}
+
+// SearchPolygonIter is the streaming version of SearchPolygon, see SearchIter.
+func (rt *Rtreed) SearchPolygonIter(poly tree.Polygon, opts ...SearchOption) iter.Seq2[tree.SpatialData, error] {
+	if rt.dim != 2 {
+		return errIter(ErrNotGeographic)
+	}
+	cfg := newSearchConfig(opts)
+	return rt.searchIter([]entryFilter{polygonFilter(poly)}, cfg, polygonAccept(poly))
+}
+
+// SearchAlongPathIter is the streaming version of SearchAlongPath, see SearchIter. objects are yielded in tree
+// order, not by distance.
+func (rt *Rtreed) SearchAlongPathIter(path []tree.Point, distance float64, opts ...SearchOption) iter.Seq2[tree.SpatialData, error] {
+	if rt.dim != 2 {
+		return errIter(ErrNotGeographic)
+	}
+	if !pathDims(path, rt.dim) {
+		return errIter(ErrDimMismatch)
+	}
+	cfg := newSearchConfig(opts)
+
+	segments := newPathSegments(path, distance)
+	return rt.searchIter([]entryFilter{segments.filter()}, cfg, segments.accept(distance))
+}
+
+// errIter. iterator yang cuma yield err.
+func errIter(err error) iter.Seq2[tree.SpatialData, error] {
+	return func(yield func(tree.SpatialData, error) bool) {
+		yield(tree.SpatialData{}, err)
+	}
+}
+
+func (rt *Rtreed) searchIter(filters []entryFilter, cfg searchConfig,
+	accept func(obj tree.SpatialData) (Result, bool)) iter.Seq2[tree.SpatialData, error] {
+	return func(yield func(tree.SpatialData, error) bool) {
+		for _, filter := range filters {
+			filter = rt.timeFilter(filter, cfg)
+			stopped := false
+			err := rt.walk(filter, func(_ types.BlockNum, node *disk.NodeByte) (bool, bool) {
+				node.ForEntriesMatch(filter.leaf, nil, func(obj tree.SpatialData) {
+					if stopped {
+						return
+					}
+					if !cfg.keep(obj) {
+						return
+					}
+					res, ok := accept(obj)
+					if !ok {
+						return
+					}
+					res.SetData(cfg.payload(obj.Data()))
+					stopped = !yield(res.SpatialData, nil)
+				})
+				return false, stopped
+			})
+			if err != nil {
+				yield(tree.SpatialData{}, err)
+				return
+			}
+			if stopped {
+				return
+			}
+		}
+	}
+}
+
+// NearestNeighborsIter yields the objects of the tree ordered by ascending distance from p, see
+// NearestIterator. breaking out after k objects reads about as many pages as NearestNeighbors(k, p).
+func (rt *Rtreed) NearestNeighborsIter(p tree.Point, opts ...SearchOption) iter.Seq2[tree.SpatialData, error] {
+	return func(yield func(tree.SpatialData, error) bool) {
+		it := rt.Nearest(p, opts...)
+		for {
+			res, ok, err := it.NextNearest()
+			if err != nil {
+				yield(tree.SpatialData{}, err)
+				return
+			}
+			if !ok || !yield(res.SpatialData, nil) {
+				return
+			}
+		}
+	}
+}
@@ -0,0 +1,150 @@
+package index
+
+import (
+	"bytes"
+	"iter"
+
+	"github.com/lintang-b-s/rtreed/lib/tree"
+	"github.com/lintang-b-s/rtreed/types"
+)
+
+// Pair is one match of a spatial join, A from the first tree and B from the second, Distance is the distance
+// between their locations (see Result).
+type Pair struct {
+	A, B     tree.SpatialData
+	Distance float64
+}
+
+// Join yields every pair of objects of a and b within distance of each other. it is a synchronized traversal
+// (Brinkhoff, Kriegel & Seeger, "Efficient Processing of Spatial Joins Using R-trees", SIGMOD 1993): both trees
+// are descended together, and only pairs of subtrees whose MBRs overlap after expanding a's MBR by distance are
+// visited. when one tree is shallower its leaves are paired with the deeper tree's subtrees.
+//
+// pages are fetched through each tree's own buffer pool and unpinned right after they are read, payloads are
+// copies. joining a tree with itself yields every pair twice and every object with itself. a and b must have the
+// same Dim, ErrDimMismatch is yielded otherwise.
+func Join(a, b *Rtreed, distance float64) iter.Seq2[Pair, error] {
+	return func(yield func(Pair, error) bool) {
+		if a.dim != b.dim {
+			yield(Pair{}, ErrDimMismatch)
+			return
+		}
+
+		m := a.metric
+		world := m.world()
+		stack := []joinTask{{aPage: a.root, aRect: world, bPage: b.root, bRect: world}}
+
+		for len(stack) > 0 {
+			task := stack[len(stack)-1]
+			stack = stack[:len(stack)-1]
+
+			aLeaf, aEntries, err := a.readEntries(task.aPage)
+			if err != nil {
+				yield(Pair{}, err)
+				return
+			}
+			bLeaf, bEntries, err := b.readEntries(task.bPage)
+			if err != nil {
+				yield(Pair{}, err)
+				return
+			}
+
+			aEntries = entriesNear(m, aEntries, task.bRect, distance, true)
+			bEntries = entriesNear(m, bEntries, task.aRect, distance, false)
+
+			switch {
+			case aLeaf && bLeaf:
+				for _, ea := range aEntries {
+					aObj := ea.GetObject()
+					for _, eb := range bEntries {
+						bObj := eb.GetObject()
+						dist := m.distance(aObj.Location(), bObj.Location())
+						if dist > distance {
+							continue
+						}
+						if !yield(Pair{A: aObj, B: bObj, Distance: dist}, nil) {
+							return
+						}
+					}
+				}
+			case aLeaf:
+				for _, eb := range bEntries {
+					stack = append(stack, joinTask{aPage: task.aPage, aRect: task.aRect, bPage: eb.GetChild(), bRect: eb.GetRect()})
+				}
+			case bLeaf:
+				for _, ea := range aEntries {
+					stack = append(stack, joinTask{aPage: ea.GetChild(), aRect: ea.GetRect(), bPage: task.bPage, bRect: task.bRect})
+				}
+			default:
+				for _, ea := range aEntries {
+					expanded := m.expand(ea.GetRect(), distance)
+					for _, eb := range bEntries {
+						if overlapsAny(expanded, eb.GetRect()) {
+							stack = append(stack, joinTask{aPage: ea.GetChild(), aRect: ea.GetRect(), bPage: eb.GetChild(), bRect: eb.GetRect()})
+						}
+					}
+				}
+			}
+		}
+	}
+}
+
+// joinTask. sepasang node yang masih harus di join, rect nya MBR node dari entry parent.
+type joinTask struct {
+	aPage types.BlockNum
+	aRect tree.Rect
+	bPage types.BlockNum
+	bRect tree.Rect
+}
+
+// readEntries. baca semua entry node lalu langsung unpin page nya. payload object di leaf dicopy.
+func (rt *Rtreed) readEntries(pageNum types.BlockNum) (bool, []tree.Entry, error) {
+	node, err := rt.getNodeByte(pageNum)
+	if err != nil {
+		return false, nil, err
+	}
+	defer rt.unpin(pageNum, false)
+
+	isLeaf := node.IsLeaf()
+	entries := make([]tree.Entry, 0, rt.maxEntries)
+	node.ForEntries(func(e tree.Entry) {
+		if isLeaf {
+			obj := e.GetObject()
+			obj.SetData(bytes.Clone(obj.Data()))
+			e.SetObject(obj)
+		}
+		entries = append(entries, e)
+	})
+	return isLeaf, entries, nil
+}
+
+// entriesNear. buang entry yang tidak mungkin punya object dalam jarak distance dari node pasangannya (other).
+// sisi a selalu yang di expand: fromA expand tiap entry, kalau tidak other (rect node a) yang di expand.
+func entriesNear(m metric, entries []tree.Entry, other tree.Rect, distance float64, fromA bool) []tree.Entry {
+	var otherExpanded []tree.Rect
+	if !fromA {
+		otherExpanded = m.expand(other, distance)
+	}
+
+	near := entries[:0]
+	for _, e := range entries {
+		if fromA {
+			if !overlapsAny(m.expand(e.GetRect(), distance), other) {
+				continue
+			}
+		} else if !overlapsAny(otherExpanded, e.GetRect()) {
+			continue
+		}
+		near = append(near, e)
+	}
+	return near
+}
+
+func overlapsAny(rects []tree.Rect, r tree.Rect) bool {
+	for _, rect := range rects {
+		if rect.Overlaps(r) {
+			return true
+		}
+	}
+	return false
+}
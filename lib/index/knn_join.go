@@ -0,0 +1,243 @@
+package index
+
+import (
+	"bytes"
+	"container/heap"
+	"iter"
+	"math"
+	"sort"
+
+	"github.com/lintang-b-s/rtreed/lib/disk"
+	"github.com/lintang-b-s/rtreed/lib/tree"
+	"github.com/lintang-b-s/rtreed/types"
+)
+
+// ClosestPairs returns the k pairs of objects of a and b with the smallest distance, closest first.
+// it is the best-first closest pairs search of Hjaltason & Samet, "Incremental Distance Join Algorithms for
+// Spatial Databases" (SIGMOD 1998): pairs of nodes wait in one priority queue ordered by the distance between
+// their MBRs, and the node with the larger MBR of the closest pair is expanded until k pairs of objects come out.
+// pairs whose lower bound is farther than the k-th closest pair of objects seen so far are never queued.
+//
+// pages are unpinned right after they are read, payloads are copies. with a == b every object pairs with itself at
+// distance 0. a and b must have the same Dim. k <= 0 returns no pairs.
+func ClosestPairs(a, b *Rtreed, k int) ([]Pair, error) {
+	if a.dim != b.dim {
+		return nil, ErrDimMismatch
+	}
+	if k <= 0 {
+		return nil, nil
+	}
+	// k dari caller bisa jauh lebih besar dari jumlah pasangan object
+	capacity := min(k, int(a.size)*int(b.size))
+
+	m := a.metric
+	world := m.world()
+	queue := &pairQueue{{a: pairSide{page: a.root, rect: world}, b: pairSide{page: b.root, rect: world}}}
+
+	// kthDists. distance k pasangan object terdekat yang sudah masuk queue, sorted.
+	kthDists := make([]float64, 0, capacity)
+	bound := func() float64 {
+		if len(kthDists) < k {
+			return math.Inf(1)
+		}
+		return kthDists[k-1]
+	}
+
+	pairs := make([]Pair, 0, capacity)
+	for queue.Len() > 0 && len(pairs) < k {
+		cand := heap.Pop(queue).(pairCandidate)
+		if cand.isObjects() {
+			pairs = append(pairs, Pair{A: cand.a.obj, B: cand.b.obj, Distance: cand.dist})
+			continue
+		}
+
+		// expand node dengan MBR yang lebih besar, object tidak bisa diexpand
+		expandA := !cand.a.isObject && (cand.b.isObject || cand.a.rect.Area() >= cand.b.rect.Area())
+		rt, side := b, cand.b
+		if expandA {
+			rt, side = a, cand.a
+		}
+
+		children, err := rt.readSides(side.page)
+		if err != nil {
+			return nil, err
+		}
+		for _, child := range children {
+			next := pairCandidate{a: cand.a, b: child}
+			if expandA {
+				next = pairCandidate{a: child, b: cand.b}
+			}
+			next.dist = next.a.distance(m, next.b)
+			if next.dist > bound() {
+				continue
+			}
+			if next.isObjects() {
+				i := sort.SearchFloat64s(kthDists, next.dist)
+				kthDists = append(kthDists, 0)
+				copy(kthDists[i+1:], kthDists[i:])
+				kthDists[i] = next.dist
+				if len(kthDists) > k {
+					kthDists = kthDists[:k]
+				}
+			}
+			heap.Push(queue, next)
+		}
+	}
+	return pairs, nil
+}
+
+// Neighbors is one object of the outer tree of KNNJoin with its nearest objects of the inner tree, nearest first.
+type Neighbors struct {
+	tree.SpatialData
+	Nearest []Result
+}
+
+// KNNJoin yields every object of a with its k nearest objects of b. a is traversed leaf by leaf, and the objects
+// of one leaf share a best-first traversal of b ordered by the distance from the leaf's MBR to b's nodes. a node of
+// b is only read while it is closer to the leaf than the k-th nearest neighbor of some object of the leaf, so b is
+// read once per leaf of a instead of once per object.
+//
+// only the current leaf of a and the node of b being read are pinned, payloads are copies. with a == b every
+// object is its own nearest neighbor. a and b must have the same Dim, ErrDimMismatch is yielded otherwise. with
+// k <= 0 every object comes with no neighbors.
+func KNNJoin(a, b *Rtreed, k int) iter.Seq2[Neighbors, error] {
+	return func(yield func(Neighbors, error) bool) {
+		if a.dim != b.dim {
+			yield(Neighbors{}, ErrDimMismatch)
+			return
+		}
+
+		all := func(tree.Rect) bool { return true }
+
+		var joinErr error
+		stopped := false
+		err := a.walk(entryFilter{node: all, leaf: all}, func(_ types.BlockNum, leaf *disk.NodeByte) (bool, bool) {
+			neighbors, err := b.leafNearest(leaf, k)
+			if err != nil {
+				joinErr = err
+				return false, true
+			}
+			for _, n := range neighbors {
+				if !yield(n, nil) {
+					stopped = true
+					return false, true
+				}
+			}
+			return false, false
+		})
+		if err == nil {
+			err = joinErr
+		}
+		if err != nil && !stopped {
+			yield(Neighbors{}, err)
+		}
+	}
+}
+
+// leafNearest. k nearest neighbor di rt untuk semua object di leaf, dalam satu traversal best-first.
+func (rt *Rtreed) leafNearest(leaf *disk.NodeByte, k int) ([]Neighbors, error) {
+	// k dari caller bisa negatif atau jauh lebih besar dari isi tree
+	capacity := max(0, min(k, int(rt.size)))
+	neighbors := make([]Neighbors, 0, rt.maxEntries)
+	var leafRect tree.Rect
+	leaf.ForEntries(func(e tree.Entry) {
+		obj := e.GetObject()
+		obj.SetData(bytes.Clone(obj.Data()))
+		if len(neighbors) == 0 {
+			leafRect = e.GetRect()
+		} else {
+			leafRect = tree.CreateRectangle(leafRect, e.GetRect())
+		}
+		neighbors = append(neighbors, Neighbors{SpatialData: obj, Nearest: make([]Result, 0, capacity)})
+	})
+	if len(neighbors) == 0 || k <= 0 {
+		return neighbors, nil
+	}
+
+	// bound. jarak k-th neighbor terjauh dari semua object leaf, node yang lebih jauh tidak perlu dibaca.
+	bound := func() float64 {
+		maxDist := 0.0
+		for _, n := range neighbors {
+			if len(n.Nearest) < k {
+				return math.Inf(1)
+			}
+			maxDist = math.Max(maxDist, n.Nearest[k-1].Distance)
+		}
+		return maxDist
+	}
+
+	queue := &nearestQueue{{page: rt.root}}
+	for queue.Len() > 0 {
+		cand := heap.Pop(queue).(nearestCandidate)
+		if cand.dist > bound() {
+			break
+		}
+
+		isLeaf, entries, err := rt.readEntries(cand.page)
+		if err != nil {
+			return nil, err
+		}
+		if !isLeaf {
+			for _, e := range entries {
+				if dist := rt.metric.rectRectDistance(leafRect, e.GetRect()); dist <= bound() {
+					heap.Push(queue, nearestCandidate{dist: dist, page: e.GetChild()})
+				}
+			}
+			continue
+		}
+
+		for i := range neighbors {
+			loc := neighbors[i].Location()
+			for _, e := range entries {
+				obj := e.GetObject()
+				dist := rt.metric.distance(loc, obj.Location())
+				neighbors[i].Nearest = insertNearest(neighbors[i].Nearest, Result{SpatialData: obj, Distance: dist}, k)
+			}
+		}
+	}
+	return neighbors, nil
+}
+
+// insertNearest. masukin res ke nearest (sorted, maksimal k) kalau lebih dekat dari yang terjauh.
+func insertNearest(nearest []Result, res Result, k int) []Result {
+	if len(nearest) == k && res.Distance >= nearest[k-1].Distance {
+		return nearest
+	}
+	i := sort.Search(len(nearest), func(i int) bool { return nearest[i].Distance > res.Distance })
+	if len(nearest) < k {
+		nearest = append(nearest, Result{})
+	}
+	copy(nearest[i+1:], nearest[i:len(nearest)-1])
+	nearest[i] = res
+	return nearest
+}
+
+// readSides. baca entry node sebagai pairSide, lihat readEntries.
+func (rt *Rtreed) readSides(pageNum types.BlockNum) ([]pairSide, error) {
+	isLeaf, entries, err := rt.readEntries(pageNum)
+	if err != nil {
+		return nil, err
+	}
+	sides := make([]pairSide, len(entries))
+	for i, e := range entries {
+		if isLeaf {
+			sides[i] = pairSide{rect: e.GetRect(), isObject: true, obj: e.GetObject()}
+		} else {
+			sides[i] = pairSide{page: e.GetChild(), rect: e.GetRect()}
+		}
+	}
+	return sides, nil
+}
+
+// distance. jarak exact kalau dua duanya object, kalau tidak lower bound nya.
+func (s pairSide) distance(m metric, other pairSide) float64 {
+	switch {
+	case s.isObject && other.isObject:
+		return m.distance(s.obj.Location(), other.obj.Location())
+	case s.isObject:
+		return m.pointRectDistance(s.obj.Location(), other.rect)
+	case other.isObject:
+		return m.pointRectDistance(other.obj.Location(), s.rect)
+	}
+	return m.rectRectDistance(s.rect, other.rect)
+}
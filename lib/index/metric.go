@@ -0,0 +1,105 @@
+package index
+
+import (
+	"math"
+
+	"github.com/lintang-b-s/rtreed/lib/tree"
+)
+
+// metric. cara tree menghitung jarak. tree 2 dimensi (lat, lon) pakai geodesic: jarak great-circle dalam km,
+// tree 3/4 dimensi pakai planar: jarak euclidean dalam satuan koordinat.
+type metric interface {
+	// distance. jarak exact dua titik.
+	distance(p, q tree.Point) float64
+	// pointRectDistance. jarak p ke titik terdekat r, lower bound jarak p ke semua object di r.
+	pointRectDistance(p tree.Point, r tree.Rect) float64
+	// rectRectDistance. lower bound jarak antara titik di r1 dan titik di r2.
+	rectRectDistance(r1, r2 tree.Rect) float64
+	// expand. rect rect yang cover semua titik dalam jarak d dari r.
+	expand(r tree.Rect, d float64) []tree.Rect
+	// world. rect yang cover semua titik.
+	world() tree.Rect
+}
+
+// newMetric. metric untuk tree dengan dim axis spatial. axis waktu tree Temporal (setelah axis spatial) tidak
+// ikut dihitung jaraknya, rect hasil expand & world tidak membatasi axis itu.
+func newMetric(dim int) metric {
+	if dim == 2 {
+		return geodesic{}
+	}
+	return planar{dims: dim}
+}
+
+// objectDistance. jarak p ke object: ke location untuk point, ke titik terdekat extent untuk yang lain.
+func objectDistance(m metric, p tree.Point, obj tree.SpatialData) float64 {
+	if obj.GeometryType() == tree.GeometryPoint {
+		return m.distance(p, obj.Location())
+	}
+	return m.pointRectDistance(p, obj.Extent())
+}
+
+type geodesic struct{}
+
+func (geodesic) distance(p, q tree.Point) float64 {
+	return haversineDistance(p.Lat, p.Lon, q.Lat, q.Lon)
+}
+
+func (geodesic) pointRectDistance(p tree.Point, r tree.Rect) float64 {
+	return pointRectDistance(p, r)
+}
+
+func (geodesic) rectRectDistance(r1, r2 tree.Rect) float64 {
+	return rectRectDistance(r1, r2)
+}
+
+func (geodesic) expand(r tree.Rect, d float64) []tree.Rect {
+	return expandRect(r, d)
+}
+
+func (geodesic) world() tree.Rect {
+	return tree.NewRectFromBounds(-90, -180, 90, 180)
+}
+
+type planar struct {
+	dims int
+}
+
+func (m planar) distance(p, q tree.Point) float64 {
+	sum := 0.0
+	for i := 0; i < m.dims; i++ {
+		d := p.Coord(i) - q.Coord(i)
+		sum += d * d
+	}
+	return math.Sqrt(sum)
+}
+
+func (m planar) pointRectDistance(p tree.Point, r tree.Rect) float64 {
+	return math.Sqrt(p.MinDist(r.Project(m.dims)))
+}
+
+func (m planar) rectRectDistance(r1, r2 tree.Rect) float64 {
+	sum := 0.0
+	for i := 0; i < m.dims; i++ {
+		gap := math.Max(r2.Min(i)-r1.Max(i), r1.Min(i)-r2.Max(i))
+		if gap > 0 {
+			sum += gap * gap
+		}
+	}
+	return math.Sqrt(sum)
+}
+
+func (m planar) expand(r tree.Rect, d float64) []tree.Rect {
+	var lower, upper [tree.MaxDims]float64
+	for i := 0; i < m.dims; i++ {
+		lower[i], upper[i] = r.Min(i)-d, r.Max(i)+d
+	}
+	return []tree.Rect{tree.NewRectFromPoints(tree.NewPointN(lower[:m.dims]...), tree.NewPointN(upper[:m.dims]...))}
+}
+
+func (m planar) world() tree.Rect {
+	var lower, upper [tree.MaxDims]float64
+	for i := 0; i < m.dims; i++ {
+		lower[i], upper[i] = math.Inf(-1), math.Inf(1)
+	}
+	return tree.NewRectFromPoints(tree.NewPointN(lower[:m.dims]...), tree.NewPointN(upper[:m.dims]...))
+}
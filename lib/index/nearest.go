@@ -0,0 +1,94 @@
+package index
+
+import (
+	"bytes"
+	"container/heap"
+
+	"github.com/lintang-b-s/rtreed/lib/tree"
+)
+
+// NearestIterator returns the objects of the tree one by one by ascending distance from a query point (see
+// Result). it is the incremental best-first search of Hjaltason & Samet, "Distance Browsing in Spatial Databases"
+// (TODS 1999): nodes and objects wait in one priority queue ordered by distance, nodes by the minimum distance
+// from the point to their rectangle, so a node is only read once it is closer than every object not yet returned.
+//
+// pages are unpinned right after they are read. the iterator reads the tree lazily, it must not be used after
+// the tree is modified.
+type NearestIterator struct {
+	rt    *Rtreed
+	p     tree.Point
+	cfg   searchConfig
+	queue nearestQueue
+	// err. dikembalikan NextNearest sebelum tree dibaca, misalnya ErrDimMismatch.
+	err error
+}
+
+// Nearest returns a NearestIterator around p. WithMaxDistance, WithFilter, WithTimeRange and WithLocationsOnly apply.
+// p must have as many axes as the tree, NextNearest returns ErrDimMismatch otherwise.
+func (rt *Rtreed) Nearest(p tree.Point, opts ...SearchOption) *NearestIterator {
+	it := &NearestIterator{
+		rt:    rt,
+		p:     p,
+		cfg:   newSearchConfig(opts),
+		queue: nearestQueue{{page: rt.root}},
+	}
+	if p.Dims() != rt.dim {
+		it.err = ErrDimMismatch
+	}
+	return it
+}
+
+// NextNearest returns the next closest object with its distance. ok is false once every object has been returned.
+func (it *NearestIterator) NextNearest() (res Result, ok bool, err error) {
+	if it.err != nil {
+		return Result{}, false, it.err
+	}
+	for it.queue.Len() > 0 {
+		cand := heap.Pop(&it.queue).(nearestCandidate)
+		if cand.isObject {
+			return Result{SpatialData: cand.obj, Distance: cand.dist}, true, nil
+		}
+
+		if err := it.expand(cand); err != nil {
+			return Result{}, false, err
+		}
+	}
+	return Result{}, false, nil
+}
+
+// expand. baca node dan masukin entry nya ke queue, kecuali yang lebih jauh dari max distance atau tidak lolos
+// filter. object di leaf dicopy karena page langsung diunpin.
+func (it *NearestIterator) expand(cand nearestCandidate) error {
+	node, err := it.rt.getNodeByte(cand.page)
+	if err != nil {
+		return err
+	}
+	defer it.rt.unpin(cand.page, false)
+
+	isLeaf := node.IsLeaf()
+	node.ForEntries(func(e tree.Entry) {
+		if !isLeaf {
+			if it.cfg.timed && it.rt.temporal && !it.cfg.overlapsTime(e.GetRect(), it.rt.dim) {
+				return
+			}
+			dist := it.rt.metric.pointRectDistance(it.p, e.GetRect())
+			if it.cfg.withinMaxDistance(dist) {
+				heap.Push(&it.queue, nearestCandidate{dist: dist, page: e.GetChild()})
+			}
+			return
+		}
+
+		obj := e.GetObject()
+		dist := objectDistance(it.rt.metric, it.p, obj)
+		if !it.cfg.withinMaxDistance(dist) || !it.cfg.keep(obj) {
+			return
+		}
+		if it.cfg.locationsOnly {
+			obj.SetData(nil)
+		} else {
+			obj.SetData(bytes.Clone(obj.Data()))
+		}
+		heap.Push(&it.queue, nearestCandidate{dist: dist, isObject: true, obj: obj})
+	})
+	return nil
+}
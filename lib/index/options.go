@@ -0,0 +1,169 @@
+package index
+
+import (
+	"fmt"
+
+	"github.com/lintang-b-s/rtreed/lib"
+	"github.com/lintang-b-s/rtreed/lib/disk"
+	"github.com/lintang-b-s/rtreed/lib/meta"
+	"github.com/lintang-b-s/rtreed/lib/tree"
+)
+
+// Options configures a tree opened with Open. every field is per instance, so two trees with
+// different settings can live side by side in one process. when reopening an existing tree, zero
+// Dim, MinEntries, MaxEntries, MaxSpatialDataInBytes & PageSize and a false Temporal are taken from the meta page.
+type Options struct {
+	// Dim is the number of axes, 2 (the default) for lat/lon points with great-circle distances in km, 3 or 4
+	// for planar points (e.g. x, y, altitude or x, y, time) with euclidean distances in coordinate units.
+	Dim                   int
+	MinEntries            int
+	MaxEntries            int
+	MaxSpatialDataInBytes int
+
+	// Temporal adds a time axis after the Dim spatial ones. every object needs a time range (see
+	// tree.SpatialData.WithTimeRange), and queries given WithTimeRange prune on it. Dim+1 must not exceed
+	// tree.MaxDims.
+	Temporal bool
+
+	// Strategy picks how inserts place entries and split nodes, Splitter how nodes are split. 0 and nil mean
+	// StrategyGuttman and the Strategy's own split. they only affect later inserts, so a tree can be reopened with
+	// others.
+	Strategy Strategy
+	Splitter Splitter
+
+	// PageSize in bytes. 0 means the smallest size in lib.PAGE_SIZE_ARRAY that fits MaxEntries entries.
+	PageSize int
+	// BufferPoolSizeInMB. 0 means lib.MAX_BUFFER_POOL_SIZE_IN_MB.
+	BufferPoolSizeInMB int
+	// BulkLoadMemoryInMB bounds the memory BulkLoadHilbert sorts with. 0 means lib.BULK_LOAD_MEMORY_IN_MB.
+	BulkLoadMemoryInMB int
+
+	// PageFileName, LogFileName & IDFileName (the id to leaf page index, see Get) are relative to the directory
+	// passed to Open.
+	PageFileName string
+	LogFileName  string
+	IDFileName   string
+}
+
+// Strategy is the insertion algorithm of a tree.
+type Strategy uint8
+
+const (
+	// StrategyGuttman is Guttman's R-tree: the subtree needing the least area enlargement and QuadraticSplit.
+	StrategyGuttman Strategy = iota
+	// StrategyRStar is the R*-tree: the subtree needing the least overlap enlargement just above the leaves,
+	// RStarSplit and, on the first overflow at each level during an insert, forced reinsertion of 30% of the node's
+	// entries instead of a split. inserts cost more, queries touch fewer overlapping nodes.
+	StrategyRStar
+	// StrategyHilbert is the Hilbert R-tree: inserts descend to the subtree whose largest Hilbert value (LHV) is the
+	// smallest one not below the new entry's, and an overflowing node first shares its entries with a cooperating
+	// sibling, splitting two full nodes into three (2-to-3 split), so nodes stay nearly full. only the root is split
+	// in two, with HilbertSplit.
+	StrategyHilbert
+)
+
+// withFileDefaults. fill empty file names with the package defaults.
+func (o Options) withFileDefaults() Options {
+	if o.PageFileName == "" {
+		o.PageFileName = lib.PAGE_FILE_NAME
+	}
+	if o.LogFileName == "" {
+		o.LogFileName = lib.LOG_FILE_NAME
+	}
+	if o.IDFileName == "" {
+		o.IDFileName = lib.ID_FILE_NAME
+	}
+	return o
+}
+
+// adoptStored. zero fields take the value stored in the meta page, non-zero fields must match it.
+func (o Options) adoptStored(m *meta.Meta) (Options, error) {
+	if m.GetMagic() != meta.Magic {
+		return o, ErrInvalidMagic
+	}
+	if m.GetVersion() != meta.FormatVersion {
+		return o, fmt.Errorf("%w: %d", ErrUnsupportedVersion, m.GetVersion())
+	}
+
+	fields := []struct {
+		name   string
+		given  *int
+		stored int
+	}{
+		{"dim", &o.Dim, m.GetDim()},
+		{"min entries", &o.MinEntries, m.GetMinEntries()},
+		{"max entries", &o.MaxEntries, m.GetMaxEntries()},
+		{"max spatial data size", &o.MaxSpatialDataInBytes, m.GetMaxSpatialDataInBytes()},
+		{"page size", &o.PageSize, m.GetPageSize()},
+	}
+	for _, f := range fields {
+		if *f.given == 0 {
+			*f.given = f.stored
+		} else if *f.given != f.stored {
+			return o, &ConfigMismatchError{Field: f.name, Stored: f.stored, Given: *f.given}
+		}
+	}
+
+	if o.Temporal && !m.IsTemporal() {
+		return o, &ConfigMismatchError{Field: "temporal", Stored: 0, Given: 1}
+	}
+	o.Temporal = m.IsTemporal()
+	return o, nil
+}
+
+// withDefaults. fill zero fields with the package defaults & validate the rest.
+func (o Options) withDefaults() (Options, error) {
+	o = o.withFileDefaults()
+	if o.Dim == 0 {
+		o.Dim = 2
+	}
+	if o.Dim < 2 || o.axes() > tree.MaxDims || o.Strategy > StrategyHilbert {
+		return o, ErrInvalidOptions
+	}
+	if o.MinEntries <= 0 || o.MaxEntries < 2*o.MinEntries || o.MaxSpatialDataInBytes < 0 {
+		return o, ErrInvalidOptions
+	}
+
+	required := disk.NodePageSize(o.axes(), o.MaxEntries, o.MaxSpatialDataInBytes)
+	if o.PageSize == 0 {
+		pageSize, err := lib.CeilPageSize(required)
+		if err != nil {
+			return o, err
+		}
+		o.PageSize = pageSize
+	} else if o.PageSize < required {
+		return o, ErrPageSizeTooSmall
+	}
+
+	if o.Splitter == nil {
+		switch o.Strategy {
+		case StrategyRStar:
+			o.Splitter = RStarSplit{}
+		case StrategyHilbert:
+			o.Splitter = HilbertSplit{}
+		default:
+			o.Splitter = QuadraticSplit{}
+		}
+	}
+
+	if o.BufferPoolSizeInMB == 0 {
+		o.BufferPoolSizeInMB = lib.MAX_BUFFER_POOL_SIZE_IN_MB
+	}
+	if o.BulkLoadMemoryInMB == 0 {
+		o.BulkLoadMemoryInMB = lib.BULK_LOAD_MEMORY_IN_MB
+	}
+	return o, nil
+}
+
+// axes. jumlah axis rect di page: Dim axis spatial, plus satu axis waktu kalau Temporal.
+func (o Options) axes() int {
+	if o.Temporal {
+		return o.Dim + 1
+	}
+	return o.Dim
+}
+
+// bufferPoolFrames. jumlah frame di buffer pool.
+func (o Options) bufferPoolFrames() int {
+	return o.BufferPoolSizeInMB * 1024 * 1024 / o.PageSize
+}
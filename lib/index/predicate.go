@@ -0,0 +1,81 @@
+package index
+
+import "github.com/lintang-b-s/rtreed/lib/tree"
+
+// Predicate is the spatial relation between an object's extent and the query rectangle used by Search.
+type Predicate int
+
+const (
+	// PredicateIntersects matches objects sharing at least one point with the query rectangle.
+	PredicateIntersects Predicate = iota
+	// PredicateWithin matches objects lying completely inside the query rectangle.
+	PredicateWithin
+	// PredicateContains matches objects that completely cover the query rectangle.
+	PredicateContains
+	// PredicateDisjoint matches objects sharing no point with the query rectangle.
+	PredicateDisjoint
+)
+
+// entryFilter decides which entries a traversal visits. node filters internal entries by their subtree MBR,
+// leaf pre-filters leaf entries by their stored rectangle before the exact test on the object.
+type entryFilter struct {
+	node func(r tree.Rect) bool
+	leaf func(r tree.Rect) bool
+}
+
+// timeFilter. tambah pruning axis waktu ke filter kalau query WithTimeRange di tree Temporal.
+func (rt *Rtreed) timeFilter(filter entryFilter, cfg searchConfig) entryFilter {
+	if !cfg.timed || !rt.temporal {
+		return filter
+	}
+	axis := rt.dim
+	return entryFilter{
+		node: func(r tree.Rect) bool { return cfg.overlapsTime(r, axis) && filter.node(r) },
+		leaf: func(r tree.Rect) bool { return cfg.overlapsTime(r, axis) && filter.leaf(r) },
+	}
+}
+
+func overlapFilter(bound tree.Rect) entryFilter {
+	return entryFilter{node: bound.Overlaps, leaf: bound.Overlaps}
+}
+
+// filter. leaf entry rect (Bounds) selalu cover extent object nya, jadi pre-filter di sini cuma boleh buang
+// entry yang pasti tidak match.
+func (pred Predicate) filter(q tree.Rect) entryFilter {
+	switch pred {
+	case PredicateContains:
+		return entryFilter{
+			node: func(r tree.Rect) bool { return r.ContainRect(q) },
+			leaf: func(r tree.Rect) bool { return r.ContainRect(q) },
+		}
+	case PredicateDisjoint:
+		// a subtree completely inside q only holds objects intersecting q
+		notInside := func(r tree.Rect) bool { return !q.ContainRect(r) }
+		return entryFilter{node: notInside, leaf: notInside}
+	default:
+		return overlapFilter(q)
+	}
+}
+
+// match. exact test predicate terhadap extent object.
+func (pred Predicate) match(extent, q tree.Rect) bool {
+	switch pred {
+	case PredicateWithin:
+		return q.ContainRect(extent)
+	case PredicateContains:
+		return extent.ContainRect(q)
+	case PredicateDisjoint:
+		return !extent.Overlaps(q)
+	default:
+		return extent.Overlaps(q)
+	}
+}
+
+func (pred Predicate) accept(q tree.Rect) func(obj tree.SpatialData) (Result, bool) {
+	return func(obj tree.SpatialData) (Result, bool) {
+		if !pred.match(obj.Extent(), q) {
+			return Result{}, false
+		}
+		return Result{SpatialData: obj}, true
+	}
+}
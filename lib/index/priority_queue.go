@@ -1,45 +1,121 @@
 package index
 
-import "github.com/lintang-b-s/rtreed/lib/tree"
+import (
+	"github.com/lintang-b-s/rtreed/lib/tree"
+	"github.com/lintang-b-s/rtreed/types"
+)
 
-type Item interface {
-	tree.SpatialData
+// nearestCandidate is a node page or an object waiting in the best-first queue, dist is the exact distance of an
+// object or the lower bound distance of every object below a node.
+type nearestCandidate struct {
+	dist     float64
+	page     types.BlockNum
+	isObject bool
+	obj      tree.SpatialData
 }
-type priorityQueueNode[T Item] struct {
-	rank  float64
-	index int
-	item  T
+
+// nearestQueue. min heap nearestCandidate berdasarkan dist. kalau dist sama object duluan.
+type nearestQueue []nearestCandidate
+
+func (q nearestQueue) Len() int {
+	return len(q)
+}
+
+func (q nearestQueue) Less(i, j int) bool {
+	if q[i].dist == q[j].dist {
+		return q[i].isObject && !q[j].isObject
+	}
+	return q[i].dist < q[j].dist
+}
+
+func (q nearestQueue) Swap(i, j int) {
+	q[i], q[j] = q[j], q[i]
+}
+
+func (q *nearestQueue) Push(x interface{}) {
+	*q = append(*q, x.(nearestCandidate))
+}
+
+func (q *nearestQueue) Pop() interface{} {
+	old := *q
+	n := len(old)
+	c := old[n-1]
+	*q = old[:n-1]
+	return c
+}
+
+// pairSide. satu sisi pairCandidate, node (page + MBR) atau object.
+type pairSide struct {
+	page     types.BlockNum
+	rect     tree.Rect
+	isObject bool
+	obj      tree.SpatialData
+}
+
+// pairCandidate is a pair of node pages or objects of two trees waiting in the closest pairs queue, dist is the
+// exact distance of two objects or the lower bound distance of every pair of objects below them.
+type pairCandidate struct {
+	dist float64
+	a, b pairSide
+}
+
+// pairQueue. min heap pairCandidate berdasarkan dist. kalau dist sama pasangan object duluan.
+type pairQueue []pairCandidate
+
+func (q pairQueue) Len() int {
+	return len(q)
+}
+
+func (q pairQueue) Less(i, j int) bool {
+	if q[i].dist == q[j].dist {
+		return q[i].isObjects() && !q[j].isObjects()
+	}
+	return q[i].dist < q[j].dist
+}
+
+func (q pairQueue) Swap(i, j int) {
+	q[i], q[j] = q[j], q[i]
+}
+
+func (q *pairQueue) Push(x interface{}) {
+	*q = append(*q, x.(pairCandidate))
+}
+
+func (q *pairQueue) Pop() interface{} {
+	old := *q
+	n := len(old)
+	c := old[n-1]
+	*q = old[:n-1]
+	return c
+}
+
+func (c pairCandidate) isObjects() bool {
+	return c.a.isObject && c.b.isObject
 }
 
-type priorityQueue[T Item] []*priorityQueueNode[T]
+// runQueue. min heap run file berdasarkan key record terdepan nya.
+type runQueue []*runReader
 
-func (pq priorityQueue[Item]) Len() int {
-	return len(pq)
+func (q runQueue) Len() int {
+	return len(q)
 }
 
-// max heap
-func (pq priorityQueue[Item]) Less(i, j int) bool {
-	return pq[i].rank > pq[j].rank
+func (q runQueue) Less(i, j int) bool {
+	return q[i].key.Less(q[j].key)
 }
 
-func (pq priorityQueue[Item]) Swap(i, j int) {
-	pq[i], pq[j] = pq[j], pq[i]
-	pq[i].index = i
-	pq[j].index = j
+func (q runQueue) Swap(i, j int) {
+	q[i], q[j] = q[j], q[i]
 }
 
-func (pq *priorityQueue[Item]) Push(x interface{}) {
-	n := len(*pq)
-	no := x.(*priorityQueueNode[Item])
-	no.index = n
-	*pq = append(*pq, no)
+func (q *runQueue) Push(x interface{}) {
+	*q = append(*q, x.(*runReader))
 }
 
-func (pq *priorityQueue[Item]) Pop() interface{} {
-	old := *pq
+func (q *runQueue) Pop() interface{} {
+	old := *q
 	n := len(old)
-	no := old[n-1]
-	no.index = -1
-	*pq = old[0 : n-1]
-	return no
+	c := old[n-1]
+	*q = old[:n-1]
+	return c
 }
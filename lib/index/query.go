@@ -0,0 +1,153 @@
+package index
+
+import (
+	"bytes"
+	"sort"
+	"time"
+
+	"github.com/lintang-b-s/rtreed/lib/tree"
+	"github.com/lintang-b-s/rtreed/types"
+)
+
+// Result is one object returned by a query. Distance is the distance from the query point to the object's extent
+// (its location for points), 0 for queries without a query point. distances are great-circle km in a 2
+// dimensional tree and euclidean, in coordinate units, in a 3 or 4 dimensional one, see Options.Dim.
+type Result struct {
+	tree.SpatialData
+	Distance float64
+
+	// page & ownsPin are set on WithZeroCopy results. the first result read from a leaf page owns that page's pin.
+	page    types.BlockNum
+	ownsPin bool
+}
+
+type searchConfig struct {
+	exact          bool
+	sortByDistance bool
+	locationsOnly  bool
+	zeroCopy       bool
+	maxDistance    float64 // 0: unlimited
+	filter         func(obj tree.SpatialData) bool
+
+	// timed: query WithTimeRange dari from sampai to (TimeCoord).
+	timed    bool
+	from, to float64
+}
+
+// SearchOption tunes a query.
+type SearchOption func(*searchConfig)
+
+// WithExactDistance drops candidates whose distance is larger than the query radius. without it
+// SearchWithinRadius returns everything inside the radius' bounding box.
+func WithExactDistance() SearchOption {
+	return func(c *searchConfig) {
+		c.exact = true
+	}
+}
+
+// WithSortByDistance orders results by ascending distance from the query point.
+func WithSortByDistance() SearchOption {
+	return func(c *searchConfig) {
+		c.sortByDistance = true
+	}
+}
+
+// WithLocationsOnly skips the stored payloads, results only carry their location.
+func WithLocationsOnly() SearchOption {
+	return func(c *searchConfig) {
+		c.locationsOnly = true
+	}
+}
+
+// WithZeroCopy returns payloads as slices of the buffer pool pages instead of copies. the pages stay pinned
+// until the results are passed to Release, and the payloads must not be used after that or after the tree is modified.
+func WithZeroCopy() SearchOption {
+	return func(c *searchConfig) {
+		c.zeroCopy = true
+	}
+}
+
+// WithMaxDistance limits nearest neighbor queries to objects within maxDistance of the query point, so they
+// may return fewer than k results. subtrees farther than maxDistance are never read.
+func WithMaxDistance(maxDistance float64) SearchOption {
+	return func(c *searchConfig) {
+		c.maxDistance = maxDistance
+	}
+}
+
+// WithFilter skips the objects for which keep returns false. it is evaluated during the traversal, before the
+// payload is copied, so skipped objects don't count toward k of a nearest neighbor query. obj's payload is a
+// slice of the page, keep must not retain it.
+func WithFilter(keep func(obj tree.SpatialData) bool) SearchOption {
+	return func(c *searchConfig) {
+		c.filter = keep
+	}
+}
+
+// WithTimeRange keeps the objects whose time range overlaps start..end, objects without a time range never
+// match. in a Temporal tree subtrees outside start..end are never read. an end before start is swapped with it.
+func WithTimeRange(start, end time.Time) SearchOption {
+	if end.Before(start) {
+		start, end = end, start
+	}
+	return func(c *searchConfig) {
+		c.timed, c.from, c.to = true, tree.TimeCoord(start), tree.TimeCoord(end)
+	}
+}
+
+// Release unpins the pages held by the results of a WithZeroCopy query. pass the slice as the query returned it
+// (reordering is fine). releasing the same slice twice is a no-op.
+func (rt *Rtreed) Release(results []Result) {
+	for i := range results {
+		if results[i].ownsPin {
+			rt.unpin(results[i].page, false)
+			results[i].ownsPin = false
+		}
+	}
+}
+
+// payload. copy data dari page kecuali zero copy, nil kalau locations only.
+func (c searchConfig) payload(data []byte) []byte {
+	if c.locationsOnly {
+		return nil
+	}
+	if c.zeroCopy {
+		return data
+	}
+	return bytes.Clone(data)
+}
+
+// keep. cek object lolos WithTimeRange & filter WithFilter.
+func (c searchConfig) keep(obj tree.SpatialData) bool {
+	if c.timed {
+		start, end, ok := obj.TimeCoords()
+		if !ok || end < c.from || start > c.to {
+			return false
+		}
+	}
+	return c.filter == nil || c.filter(obj)
+}
+
+// overlapsTime. cek axis waktu rect r overlap dengan WithTimeRange.
+func (c searchConfig) overlapsTime(r tree.Rect, axis int) bool {
+	return r.Max(axis) >= c.from && r.Min(axis) <= c.to
+}
+
+// withinMaxDistance. cek dist tidak melebihi WithMaxDistance.
+func (c searchConfig) withinMaxDistance(dist float64) bool {
+	return c.maxDistance <= 0 || dist <= c.maxDistance
+}
+
+func newSearchConfig(opts []SearchOption) searchConfig {
+	var c searchConfig
+	for _, opt := range opts {
+		opt(&c)
+	}
+	return c
+}
+
+func sortResultsByDistance(results []Result) {
+	sort.SliceStable(results, func(i, j int) bool {
+		return results[i].Distance < results[j].Distance
+	})
+}
@@ -0,0 +1,144 @@
+package index
+
+import (
+	"math"
+	"sort"
+
+	"github.com/lintang-b-s/rtreed/lib/buffer"
+	"github.com/lintang-b-s/rtreed/lib/tree"
+	"github.com/lintang-b-s/rtreed/types"
+)
+
+// R*-tree, Beckmann et al. 1990.
+
+const (
+	// rstarCandidates. jumlah entry dengan area enlargement terkecil yang dihitung overlap enlargement nya di
+	// ChooseSubtree (nearly minimum overlap cost), biar tidak kuadratik di node yang besar.
+	rstarCandidates = 32
+	// reinsertFraction. bagian entry node yang overflow yang di-forced reinsert.
+	reinsertFraction = 0.3
+)
+
+// overflow. state overflow treatment selama insert satu entry: level yang sudah di-forced reinsert, & entry yang
+// dikeluarkan dari node beserta level nya, diinsert ulang setelah insert entry tsb selesai.
+type overflow struct {
+	reinserted map[int]bool
+	level      int
+	entries    []*tree.Entry
+}
+
+func newOverflow() *overflow {
+	return &overflow{reinserted: make(map[int]bool)}
+}
+
+// overflowTreatment. node di nPage kelebihan entry. dengan StrategyRStar overflow pertama di tiap level (selain root)
+// selama satu insertion di-handle dengan forced reinsert, node nya tidak di-split. dengan StrategyHilbert node selain
+// root ditangani deferredSplit. selain itu node di-split.
+func (rt *Rtreed) overflowTreatment(nPage *buffer.Buffer, ov *overflow,
+	needToUnpin *[]unpinPage) (*buffer.Buffer, *buffer.Buffer, error) {
+	n := nPage.DeserializeNode()
+	if rt.strategy == StrategyHilbert && n.GetPageNum() != rt.root {
+		return rt.deferredSplit(nPage, n, needToUnpin)
+	}
+	if rt.strategy != StrategyRStar || n.GetPageNum() == rt.root || ov.reinserted[n.Level()] {
+		return rt.splitNode(nPage, rt.minEntries, needToUnpin)
+	}
+
+	ov.reinserted[n.Level()] = true
+	keep, removed := pickReinsert(n.GetEntries(), max(1, int(reinsertFraction*float64(rt.maxEntries))))
+	n.SetEntries(keep)
+	nPage.SerializeNode(n)
+	markDirty(needToUnpin, n.GetPageNum())
+
+	ov.level, ov.entries = n.Level(), removed
+	return nPage, nil, nil
+}
+
+// pickReinsert. p entry yang titik tengah rect nya paling jauh dari titik tengah node dikeluarkan. entry yang
+// dikeluarkan urut dari yang paling dekat (close reinsert).
+func pickReinsert(entries []*tree.Entry, p int) ([]*tree.Entry, []*tree.Entry) {
+	bound := createNodeRectangle(*tree.NewNode(entries, 0, 0, false))
+	dist := func(e *tree.Entry) float64 {
+		r := e.GetRect()
+		sum := 0.0
+		for i := 0; i < bound.Dims(); i++ {
+			d := (r.Min(i)+r.Max(i))/2 - (bound.Min(i)+bound.Max(i))/2
+			sum += d * d
+		}
+		return sum
+	}
+
+	sorted := append([]*tree.Entry(nil), entries...)
+	sort.SliceStable(sorted, func(i, j int) bool { return dist(sorted[i]) < dist(sorted[j]) })
+	return sorted[:len(sorted)-p], sorted[len(sorted)-p:]
+}
+
+// chooseSubtree. child n tempat e diinsert. StrategyRStar: kalau child n leaf, pilih yang overlap enlargement nya
+// paling kecil, selain itu (& Guttman) yang area enlargement nya paling kecil. StrategyHilbert: lihat chooseByLHV.
+func (rt *Rtreed) chooseSubtree(n *tree.Node, e *tree.Entry) types.BlockNum {
+	if rt.strategy == StrategyHilbert {
+		return chooseByLHV(n.GetEntries(), e.GetLHV())
+	}
+	if rt.strategy == StrategyRStar && n.Level() == 2 {
+		return chooseLeastOverlapEnlargement(n.GetEntries(), e)
+	}
+	return chooseLeastEnlargement(n.GetEntries(), e)
+}
+
+// chooseLeastOverlapEnlargement. entry yang kalau diperbesar dengan e overlap nya dengan entry lain paling sedikit
+// bertambah, seri dipecah dengan area enlargement lalu area.
+func chooseLeastOverlapEnlargement(entries []*tree.Entry, e *tree.Entry) types.BlockNum {
+	if len(entries) == 0 {
+		return 0
+	}
+
+	type candidate struct {
+		en       *tree.Entry
+		enlarged tree.Rect
+		areaDiff float64
+	}
+	candidates := make([]candidate, len(entries))
+	for i, en := range entries {
+		enlarged := tree.CreateRectangle(en.GetRect(), e.GetRect())
+		candidates[i] = candidate{en, enlarged, enlarged.Area() - en.GetRect().Area()}
+	}
+	sort.SliceStable(candidates, func(i, j int) bool {
+		if candidates[i].areaDiff != candidates[j].areaDiff {
+			return candidates[i].areaDiff < candidates[j].areaDiff
+		}
+		return candidates[i].en.GetRect().Area() < candidates[j].en.GetRect().Area()
+	})
+	candidates = candidates[:min(len(candidates), rstarCandidates)]
+
+	// candidates sudah urut area enlargement & area, jadi seri overlap dimenangkan yang lebih dulu
+	best, bestOverlap := 0, math.Inf(1)
+	for i, c := range candidates {
+		overlap := 0.0
+		for _, other := range entries {
+			// entry yang tidak kena rect yang diperbesar juga tidak kena rect aslinya
+			if other != c.en && c.enlarged.Overlaps(other.GetRect()) {
+				overlap += overlapArea(c.enlarged, other.GetRect()) - overlapArea(c.en.GetRect(), other.GetRect())
+			}
+		}
+		if overlap < bestOverlap {
+			best, bestOverlap = i, overlap
+		}
+	}
+	return candidates[best].en.GetChild()
+}
+
+// overlapArea. luas irisan r1 & r2, 0 kalau tidak beririsan.
+func overlapArea(r1, r2 tree.Rect) float64 {
+	if !r1.Overlaps(r2) {
+		return 0
+	}
+	area := 1.0
+	for i := 0; i < min(r1.Dims(), r2.Dims()); i++ {
+		side := math.Min(r1.Max(i), r2.Max(i)) - math.Max(r1.Min(i), r2.Min(i))
+		if side <= 0 {
+			return 0
+		}
+		area *= side
+	}
+	return area
+}
@@ -2,12 +2,9 @@ package index
 
 import (
 	"bytes"
-	"container/heap"
-	"errors"
-	"fmt"
 	"math"
 	"os"
-	"sort"
+	"path/filepath"
 
 	"github.com/lintang-b-s/rtreed/lib"
 	"github.com/lintang-b-s/rtreed/lib/buffer"
@@ -25,37 +22,79 @@ type Rtreed struct {
 	metadata          *meta.Meta
 	root              types.BlockNum
 	dim               int
+	temporal          bool
+	metric            metric
+	strategy          Strategy
+	splitter          Splitter
 	minEntries        int
 	maxEntries        int
 	size              int32
 	height            int
+	pageSize          int
+	pageFileName      string
+	idFileName        string
+	idBlocks          int // jumlah page di file id index
+	bulkLoadMemory    int // budget memory BulkLoadHilbert dalam byte
 }
 
+// NewRtreed opens the tree stored in lib.DB_DIR with the default page & log file names.
 func NewRtreed(dim, min, max, maxSpatialDataInBytes int) (*Rtreed, error) {
-	// max_page_size =  max_page_size =   21 bytes + maxEntries * (10 + 48 + 8 + maxSpatialDataInBytes) bytes size  [see page.go SerializeNode()]
-	var err error
-	lib.MAX_PAGE_SIZE = 21 + max*(10+48+8+maxSpatialDataInBytes)
-	lib.MAX_BUFFER_POOL_SIZE = lib.MAX_BUFFER_POOL_SIZE_IN_MB * 1024 * 1024 / lib.MAX_PAGE_SIZE
-	lib.MAX_PAGE_SIZE, err = lib.CeilPageSize(lib.MAX_PAGE_SIZE)
-	_, err = os.Stat(lib.DB_DIR)
-	if !os.IsNotExist(err) {
-		// db exists
-		dm := disk.NewDiskManager(lib.DB_DIR, lib.MAX_PAGE_SIZE)
-		lm, err := log.NewLogManager(dm, lib.LOG_FILE_NAME)
+	return Open(lib.DB_DIR, Options{
+		Dim:                   dim,
+		MinEntries:            min,
+		MaxEntries:            max,
+		MaxSpatialDataInBytes: maxSpatialDataInBytes,
+	})
+}
+
+// Open opens the tree stored in dir, creating dir and an empty tree if the page file does not exist yet.
+func Open(dir string, opts Options) (*Rtreed, error) {
+	opts = opts.withFileDefaults()
+
+	_, err := os.Stat(filepath.Join(dir, opts.PageFileName))
+	dbExists := !os.IsNotExist(err)
+
+	if dbExists {
+		stored, err := readStoredMeta(dir, opts.PageFileName)
 		if err != nil {
-			panic(err)
+			return nil, err
 		}
-		bufferPoolManager := buffer.NewBufferPoolManager(lib.MAX_BUFFER_POOL_SIZE, dm, lm, 1)
-
-		rt := &Rtreed{
-			dim:               dim,
-			minEntries:        min,
-			maxEntries:        max,
-			diskManager:       dm,
-			logManager:        lm,
-			bufferPoolManager: bufferPoolManager,
+		opts, err = opts.adoptStored(stored)
+		if err != nil {
+			return nil, err
 		}
+	}
 
+	opts, err = opts.withDefaults()
+	if err != nil {
+		return nil, err
+	}
+
+	dm := disk.NewDiskManager(dir, opts.PageSize)
+	lm, err := log.NewLogManager(dm, opts.LogFileName)
+	if err != nil {
+		return nil, err
+	}
+	bufferPoolManager := buffer.NewBufferPoolManager(opts.bufferPoolFrames(), dm, lm, 1, opts.PageFileName)
+
+	rt := &Rtreed{
+		dim:               opts.Dim,
+		temporal:          opts.Temporal,
+		metric:            newMetric(opts.Dim),
+		strategy:          opts.Strategy,
+		splitter:          opts.Splitter,
+		minEntries:        opts.MinEntries,
+		maxEntries:        opts.MaxEntries,
+		pageSize:          opts.PageSize,
+		pageFileName:      opts.PageFileName,
+		idFileName:        opts.IDFileName,
+		bulkLoadMemory:    opts.BulkLoadMemoryInMB * 1024 * 1024,
+		diskManager:       dm,
+		logManager:        lm,
+		bufferPoolManager: bufferPoolManager,
+	}
+
+	if dbExists {
 		meta, err := rt.readMeta()
 		if err != nil {
 			return nil, err
@@ -66,55 +105,98 @@ func NewRtreed(dim, min, max, maxSpatialDataInBytes int) (*Rtreed, error) {
 		rt.root = rt.metadata.GetRoot()
 		rt.height = rt.metadata.GetHeight()
 		rt.size = rt.metadata.GetSize()
-		return rt, nil
-
-	} else if os.IsNotExist(err) {
-		// db not exist, create new
-		dm := disk.NewDiskManager(lib.DB_DIR, lib.MAX_PAGE_SIZE)
-		lm, err := log.NewLogManager(dm, lib.LOG_FILE_NAME)
+		rt.idBlocks, err = dm.BlockLength(opts.IDFileName)
 		if err != nil {
-			panic(err)
+			return nil, err
 		}
-		bufferPoolManager := buffer.NewBufferPoolManager(lib.MAX_BUFFER_POOL_SIZE, dm, lm, 1)
+		return rt, nil
+	}
 
-		rt := &Rtreed{
-			dim:               dim,
-			minEntries:        min,
-			maxEntries:        max,
-			diskManager:       dm,
-			logManager:        lm,
-			bufferPoolManager: bufferPoolManager,
-			metadata:          meta.NewEmptyMeta(),
-		}
+	// db not exist, create new. id index sisa tree lama dengan nama file yang sama dibuang
+	err = os.Remove(filepath.Join(dir, opts.IDFileName))
+	if err != nil && !os.IsNotExist(err) {
+		return nil, err
+	}
+	rt.metadata = meta.NewEmptyMeta()
+	rt.metadata.SetDim(opts.Dim)
+	rt.metadata.SetTemporal(opts.Temporal)
+	rt.metadata.SetMinEntries(opts.MinEntries)
+	rt.metadata.SetMaxEntries(opts.MaxEntries)
+	rt.metadata.SetMaxSpatialDataInBytes(opts.MaxSpatialDataInBytes)
+	rt.metadata.SetPageSize(opts.PageSize)
+	rt.root = 1
+	rt.metadata.SetRoot(rt.root)
+	rt.metadata.SetHeight(rt.height)
+	rt.metadata.SetSize(rt.size)
+	err = rt.writeMeta()
+	if err != nil {
+		return nil, err
+	}
 
-		rt.root = 1
-		rt.metadata.SetRoot(rt.root)
-		rt.metadata.SetHeight(rt.height)
-		rt.metadata.SetSize(rt.size)
-		err = rt.writeMeta()
-		if err != nil {
-			return nil, err
-		}
+	rootNode := tree.NewNode([]*tree.Entry{}, 0, 1, true)
+	rootNode.SetPageNum(2) // initial root page num is 2
+	rootNode, err = rt.writeRootNode(rootNode)
+	if err != nil {
+		return nil, err
+	}
+	rt.bufferPoolManager.UnpinPage(disk.NewBlockID(rt.pageFileName, int(rootNode.GetPageNum())), true)
 
-		rootNode := tree.NewNode([]*tree.Entry{}, 0, 1, true)
-		rootNode.SetPageNum(2) // initial root page num is 2
-		rootNode, err = rt.writeRootNode(rootNode)
-		if err != nil {
-			return nil, err
-		}
-		rt.bufferPoolManager.UnpinPage(disk.NewBlockID(lib.PAGE_FILE_NAME, int(rootNode.GetPageNum())), true)
+	return rt, nil
+}
 
-		return rt, nil
+// Insert adds obj to the tree, see InsertID.
+func (rt *Rtreed) Insert(obj tree.SpatialData) error {
+	_, err := rt.InsertID(obj)
+	return err
+}
+
+// InsertID adds obj to the tree and returns the id assigned to it. objects read back from the tree carry their id
+// (see tree.SpatialData.ID), and Get, DeleteByID, UpdateByID & Move find an object by id without comparing payloads.
+// obj must have as many axes as the tree, ErrDimMismatch otherwise, and a time range that does not end before it
+// starts exactly when the tree is Temporal, ErrTimeRange otherwise. data longer than MaxSpatialDataInBytes is
+// rejected with ErrPayloadTooLarge, a line or polygon without points with ErrEmptyGeometry.
+// an id already on obj is replaced by a fresh one.
+func (rt *Rtreed) InsertID(obj tree.SpatialData) (uint64, error) {
+	if err := rt.checkObject(obj); err != nil {
+		return 0, err
 	}
-	return nil, nil
+	id := rt.metadata.GetNextID()
+	if err := rt.insertObject(obj.WithID(id)); err != nil {
+		return 0, err
+	}
+	rt.metadata.SetNextID(id + 1)
+	return id, nil
+}
+
+// checkObject. cek extent obj tidak kosong, jumlah axis, interval waktu & ukuran data obj cocok dengan tree. data
+// yang lebih besar dari MaxSpatialDataInBytes tidak muat di page leaf.
+func (rt *Rtreed) checkObject(obj tree.SpatialData) error {
+	if obj.Extent().IsEmpty() {
+		return ErrEmptyGeometry
+	}
+	if obj.Extent().Dims() != rt.dim {
+		return ErrDimMismatch
+	}
+	if start, end, timed := obj.TimeCoords(); timed != rt.temporal || start > end {
+		return ErrTimeRange
+	}
+	if len(obj.Data()) > rt.metadata.GetMaxSpatialDataInBytes() {
+		return ErrPayloadTooLarge
+	}
+	return nil
 }
 
-func (rt *Rtreed) Insert(obj tree.SpatialData) {
+// insertObject. insert obj yang id nya sudah diset.
+func (rt *Rtreed) insertObject(obj tree.SpatialData) error {
 	e := tree.NewEntry(obj.Bounds(), lib.NEW_PAGE_NUM, obj)
-	rt.insert(e, 1)
+	err := rt.insert(e, 1, newOverflow())
+	if err != nil {
+		return err
+	}
 
 	rt.size++
 	rt.updateMetaHeightSeize(rt.height, rt.size)
+	return nil
 }
 
 type unpinPage struct {
@@ -134,96 +216,119 @@ func newUnpinPage(pageNum types.BlockNum, isDirty bool) unpinPage {
 	return unpinPage{pageNum, isDirty}
 }
 
-func (rt *Rtreed) insert(e *tree.Entry, level int) {
+// insert adds e to a node at the given level (leaves are level 1). entries with a child page keep their subtree.
+// ov carries the forced reinsertions of the object insertion e belongs to, see overflowTreatment.
+func (rt *Rtreed) insert(e *tree.Entry, level int, ov *overflow) error {
 
 	needToUnpin := make([]unpinPage, 0, 10)
+	defer func() {
+		rt.unpinAll(needToUnpin)
+	}()
 
-	root, rootPage, err := rt.getNodeAndPage(rt.root)
+	root, rootPage, err := rt.fetchNode(rt.root, &needToUnpin)
 	if err != nil {
-		panic(err)
+		return err
 	}
-	needToUnpin = append(needToUnpin, newUnpinPage(root.GetPageNum(), false))
 
 	var leaf *tree.Node
 	var leafPage *buffer.Buffer
 	if level != 1 {
-		leaf, leafPage = rt.chooseNode(root, rootPage, e, level, &needToUnpin)
+		leaf, leafPage, err = rt.chooseNode(root, rootPage, e, level, &needToUnpin)
 	} else {
-		leaf, leafPage = rt.chooseLeaf(root, rootPage, e, &needToUnpin)
+		leaf, leafPage, err = rt.chooseLeaf(root, rootPage, e, &needToUnpin)
+	}
+	if err != nil {
+		return err
 	}
 
 	leaf.AppendEntry(e)
 
 	if e.GetChild() != lib.NEW_PAGE_NUM {
 		// set parent
-		eChild, eChildPage, err := rt.getNodeAndPage(e.GetChild())
+		err = rt.setParent(e.GetChild(), leaf.GetPageNum(), &needToUnpin)
 		if err != nil {
-			panic(err)
+			return err
 		}
-		eChild.SetParent(leaf.GetPageNum())
-		eChildPage.SerializeNode(eChild)
-		needToUnpin = append(needToUnpin, newUnpinPage(eChild.GetPageNum(), true))
-
-		leaf.SetEntry(len(leaf.GetEntries())-1, tree.NewEntry(e.GetRect(), eChild.GetPageNum(), e.GetObject()))
-		leafPage.SerializeNode(leaf)
-		needToUnpin = append(needToUnpin, newUnpinPage(leaf.GetPageNum(), true))
 	}
 
 	leafPage.SerializeNode(leaf)
-	needToUnpin = append(needToUnpin, newUnpinPage(leaf.GetPageNum(), true))
+	markDirty(&needToUnpin, leaf.GetPageNum())
+
+	if leaf.IsLeaf() {
+		// kalau leaf di-split & e pindah ke page baru, splitNode yang update lagi
+		obj := e.GetObject()
+		err = rt.setIDPage(obj.ID(), leaf.GetPageNum())
+		if err != nil {
+			return err
+		}
+	}
 
-	leafIsRoot := leaf.GetPageNum() == root.GetPageNum()
-	var ll *tree.Node
 	var llPage *buffer.Buffer
 	if leaf.GetEntriesSize() > rt.maxEntries {
-		leafPage, llPage = rt.splitNode(leafPage, rt.minEntries, &needToUnpin)
+		leafPage, llPage, err = rt.overflowTreatment(leafPage, ov, &needToUnpin)
+		if err != nil {
+			return err
+		}
 	}
 
-	rootPage, splitRootPage := rt.adjustTree(leafPage, llPage, &needToUnpin, leafIsRoot)
-
-	root = rootPage.DeserializeNode()
+	rootPage, splitRootPage, err := rt.adjustTree(leafPage, llPage, ov, &needToUnpin)
+	if err != nil {
+		return err
+	}
 
 	if splitRootPage != nil {
-		ll = splitRootPage.DeserializeNode()
-		oldRoot := root
-		rt.height++
-
-		newRoot := &tree.Node{}
-		newRoot.SetPageNum(lib.NEW_PAGE_NUM)
-
-		newRootEntries := make([]*tree.Entry, 0, 2)
-		newRootEntries = append(newRootEntries, tree.NewEntry(createNodeRectangle(*oldRoot), oldRoot.GetPageNum(), tree.SpatialData{}))
-		newRootEntries = append(newRootEntries, tree.NewEntry(createNodeRectangle(*ll), ll.GetPageNum(), tree.SpatialData{}))
-
-		newRoot.SetEntries(newRootEntries)
-		newRoot.SetParent(0)
-		newRoot.SetIsleaf(false)
-		newRoot.SetLevel(rt.height)
-		newRoot.SetEntries(newRootEntries)
+		err = rt.growTree(rootPage, splitRootPage, &needToUnpin)
+		if err != nil {
+			return err
+		}
+	}
 
-		newRootUpdated, err := rt.writeNode(newRoot)
+	if len(ov.entries) == 0 {
+		return nil
+	}
+	// entry hasil forced reinsert diinsert ulang di level asalnya setelah page insert ini diunpin
+	rt.unpinAll(needToUnpin)
+	needToUnpin = needToUnpin[:0]
+
+	reinsertLevel, reinsertEntries := ov.level, ov.entries
+	ov.entries = nil
+	for _, re := range reinsertEntries {
+		err = rt.insert(re, reinsertLevel, ov)
 		if err != nil {
-			panic(err)
+			return err
 		}
-		needToUnpin = append(needToUnpin, newUnpinPage(newRootUpdated.GetPageNum(), true))
+	}
+	return nil
+}
 
-		rt.root = newRootUpdated.GetPageNum()
-		rt.upateMetaRoot(newRootUpdated.GetPageNum())
+// growTree. root node di-split, buat root baru yang entries nya old root & hasil split nya.
+func (rt *Rtreed) growTree(rootPage, splitRootPage *buffer.Buffer, needToUnpin *[]unpinPage) error {
+	oldRoot := rootPage.DeserializeNode()
+	ll := splitRootPage.DeserializeNode()
+	rt.height++
 
-		oldRoot.SetParent(rt.root)
-		ll.SetParent(rt.root)
+	newRootEntries := make([]*tree.Entry, 0, 2)
+	newRootEntries = append(newRootEntries, parentEntryFor(oldRoot), parentEntryFor(ll))
 
-		splitRootPage.SerializeNode(ll)
-		rootPage.SerializeNode(oldRoot)
+	newRoot := tree.NewNode(newRootEntries, 0, oldRoot.Level()+1, false)
 
-		needToUnpin = append(needToUnpin, newUnpinPage(ll.GetPageNum(), true))
-		needToUnpin = append(needToUnpin, newUnpinPage(oldRoot.GetPageNum(), true))
+	newRootUpdated, _, err := rt.writeNodeAndGetPage(newRoot, needToUnpin)
+	if err != nil {
+		return err
 	}
 
-	for _, p := range needToUnpin {
-		blockId := disk.NewBlockID(lib.PAGE_FILE_NAME, int(p.getPageNum()))
-		rt.bufferPoolManager.UnpinPage(blockId, p.getIsDirty())
-	}
+	rt.root = newRootUpdated.GetPageNum()
+	rt.upateMetaRoot(newRootUpdated.GetPageNum())
+
+	oldRoot.SetParent(rt.root)
+	ll.SetParent(rt.root)
+
+	splitRootPage.SerializeNode(ll)
+	rootPage.SerializeNode(oldRoot)
+
+	markDirty(needToUnpin, ll.GetPageNum())
+	markDirty(needToUnpin, oldRoot.GetPageNum())
+	return nil
 }
 
 func chooseLeastEnlargement(entries []*tree.Entry, e *tree.Entry) types.BlockNum {
@@ -232,143 +337,129 @@ func chooseLeastEnlargement(entries []*tree.Entry, e *tree.Entry) types.BlockNum
 	for _, en := range entries {
 		rect := tree.CreateRectangle(en.GetRect(), e.GetRect())
 		currDiff := rect.Area() - en.GetRect().Area()
-		if currDiff < rectAreaDiff || (currDiff == rectAreaDiff && en.GetRect().Area() < leastEnlEntry.GetRect().Area()) {
+		if leastEnlEntry == nil || currDiff < rectAreaDiff ||
+			(currDiff == rectAreaDiff && en.GetRect().Area() < leastEnlEntry.GetRect().Area()) {
 			rectAreaDiff = currDiff
 			leastEnlEntry = en
 		}
 	}
 
+	if leastEnlEntry == nil {
+		return 0
+	}
 	return types.BlockNum(leastEnlEntry.GetChild())
 }
 
 // chooseNode finds the node at the specified level to which e should be added.
 func (rt *Rtreed) chooseNode(n *tree.Node, nPage *buffer.Buffer, e *tree.Entry, level int,
-	needToUnpin *[]unpinPage) (*tree.Node, *buffer.Buffer) {
-	if n.Level() == level {
-		return n, nPage
+	needToUnpin *[]unpinPage) (*tree.Node, *buffer.Buffer, error) {
+	if n.Level() == level || n.IsLeaf() {
+		return n, nPage, nil
 	}
-	chosenChild := chooseLeastEnlargement(n.GetEntries(), e)
+	chosenChild := rt.chooseSubtree(n, e)
 
 	if chosenChild == 0 {
-		return n, nPage
+		return n, nPage, nil
 	}
-	child, childPage, err := rt.getNodeAndPage(chosenChild)
+	child, childPage, err := rt.fetchNode(chosenChild, needToUnpin)
 	if err != nil {
-		panic(err)
+		return nil, nil, err
 	}
 
-	*needToUnpin = append(*needToUnpin, newUnpinPage(chosenChild, false))
 	return rt.chooseNode(child, childPage, e, level, needToUnpin)
 }
 
-func (rt *Rtreed) chooseLeaf(n *tree.Node, nPage *buffer.Buffer, e *tree.Entry, needToUnpin *[]unpinPage) (*tree.Node, *buffer.Buffer) {
+func (rt *Rtreed) chooseLeaf(n *tree.Node, nPage *buffer.Buffer, e *tree.Entry, needToUnpin *[]unpinPage) (*tree.Node, *buffer.Buffer, error) {
 
 	if n.IsLeaf() {
-		return n, nPage
+		return n, nPage, nil
 	}
 
-	chosenChild := chooseLeastEnlargement(n.GetEntries(), e)
+	chosenChild := rt.chooseSubtree(n, e)
 
 	if chosenChild == 0 {
-		return n, nPage
+		return n, nPage, nil
 	}
 
-	child, childPage, err := rt.getNodeAndPage(chosenChild)
+	child, childPage, err := rt.fetchNode(chosenChild, needToUnpin)
 	if err != nil {
-		panic(err)
-	}
-	en, _ := rt.getNFromParentEntry(child, needToUnpin)
-	if en == nil {
-		fmt.Printf("debug")
+		return nil, nil, err
 	}
 
-	*needToUnpin = append(*needToUnpin, newUnpinPage(chosenChild, false))
 	return rt.chooseLeaf(child, childPage, e, needToUnpin)
 }
 
-func (rt *Rtreed) adjustTree(lPage, llPage *buffer.Buffer, needToUnpin *[]unpinPage, leafIsRoot bool) (*buffer.Buffer, *buffer.Buffer) {
-	root, rootPage, err := rt.getNodeAndPage(rt.root)
-	if err != nil {
-		panic(err)
-	}
-	*needToUnpin = append(*needToUnpin, newUnpinPage(root.GetPageNum(), leafIsRoot))
+// adjustTree ascends from node l to the root, adjusting covering rectangles and subtree counts and propagating
+// node splits. it returns the root page and the page of the root's split sibling, nil when the root wasn't split.
+func (rt *Rtreed) adjustTree(lPage, llPage *buffer.Buffer, ov *overflow,
+	needToUnpin *[]unpinPage) (*buffer.Buffer, *buffer.Buffer, error) {
 	l := lPage.DeserializeNode()
 
-	if l.GetPageNum() == root.GetPageNum() {
-
-		lPage.SerializeNode(l)
-		if llPage != nil {
-			ll := llPage.DeserializeNode()
-			llPage.SerializeNode(ll)
-			*needToUnpin = append(*needToUnpin, newUnpinPage(ll.GetPageNum(), true))
-		}
-		*needToUnpin = append(*needToUnpin, newUnpinPage(l.GetPageNum(), true))
-		return lPage, llPage
+	if l.GetPageNum() == rt.root {
+		return lPage, llPage, nil
 	}
 
-	en, idx := rt.getNFromParentEntry(l, needToUnpin)
-
-	prevRect := en.GetRect()
-	en.SetRect(createNodeRectangle(*l))
-	en.SetChild(l.GetPageNum())
-
-	lParent, lParentPage, err := rt.getNodeAndPage(l.GetParent())
+	lParent, lParentPage, err := rt.fetchNode(l.GetParent(), needToUnpin)
 	if err != nil {
-		panic(err)
+		return nil, nil, err
+	}
+	idx := entryIndexOf(lParent, l.GetPageNum())
+	if idx < 0 {
+		return nil, nil, ErrCorruptTree
 	}
-	lParent.SetEntry(idx, en)
 
-	if llPage == nil {
+	// rect & subtree count entry l di parent di update sampai root, count selalu berubah jadi tidak bisa
+	// berhenti walaupun rect nya sama
+	updateParentEntry(lParent.GetEntry(idx), l)
 
-		lPage.SerializeNode(l)
+	if llPage == nil {
 		lParentPage.SerializeNode(lParent)
-
-		*needToUnpin = append(*needToUnpin, newUnpinPage(l.GetPageNum(), true))
-		*needToUnpin = append(*needToUnpin, newUnpinPage(lParent.GetPageNum(), true))
-
-		if en.GetRect().Equal(prevRect) {
-			return rootPage, nil
-		}
-		return rt.adjustTree(lParentPage, nil, needToUnpin, leafIsRoot)
+		markDirty(needToUnpin, lParent.GetPageNum())
+		return rt.adjustTree(lParentPage, nil, ov, needToUnpin)
 	}
 
 	ll := llPage.DeserializeNode()
+	lParent.AppendEntry(parentEntryFor(ll))
 
-	ell := tree.NewEntry(createNodeRectangle(*ll), ll.GetPageNum(), tree.SpatialData{})
-	lParent.AppendEntry(ell)
-
-	llPage.SerializeNode(ll)
-	lPage.SerializeNode(l)
 	lParentPage.SerializeNode(lParent)
-
-	*needToUnpin = append(*needToUnpin, newUnpinPage(lParent.GetPageNum(), true))
-	*needToUnpin = append(*needToUnpin, newUnpinPage(l.GetPageNum(), true))
-	*needToUnpin = append(*needToUnpin, newUnpinPage(ll.GetPageNum(), true))
+	markDirty(needToUnpin, lParent.GetPageNum())
 
 	if len(lParent.GetEntries()) > rt.maxEntries {
-		newl, newll := rt.splitNode(lParentPage, rt.minEntries, needToUnpin)
+		newl, newll, err := rt.overflowTreatment(lParentPage, ov, needToUnpin)
+		if err != nil {
+			return nil, nil, err
+		}
 
-		return rt.adjustTree(newl, newll, needToUnpin, leafIsRoot)
+		return rt.adjustTree(newl, newll, ov, needToUnpin)
 	}
-	return rt.adjustTree(lParentPage, nil, needToUnpin, leafIsRoot)
+	return rt.adjustTree(lParentPage, nil, ov, needToUnpin)
 
 }
 
-func (rt *Rtreed) getNFromParentEntry(n *tree.Node, needToUnpin *[]unpinPage) (*tree.Entry, int) {
-	var e *tree.Entry
-	idx := -1
-	nParent, err := rt.getNode(n.GetParent())
-	if err != nil {
-		panic(err)
-	}
-	*needToUnpin = append(*needToUnpin, newUnpinPage(nParent.GetPageNum(), false))
-	for i := range nParent.GetEntries() {
-		if nParent.GetEntries()[i].GetChild() == n.GetPageNum() {
-			return nParent.GetEntries()[i], i
+// parentEntryFor returns the entry pointing to n in its parent, covering n's entries, counting their objects and
+// carrying their largest Hilbert value.
+func parentEntryFor(n *tree.Node) *tree.Entry {
+	e := tree.NewEntry(createNodeRectangle(*n), n.GetPageNum(), tree.SpatialData{})
+	e.SetCount(n.Count())
+	e.SetLHV(largestLHV(n.GetEntries()))
+	return e
+}
+
+// updateParentEntry. samakan rect, count & LHV entry di parent dengan isi node n.
+func updateParentEntry(e *tree.Entry, n *tree.Node) {
+	e.SetRect(createNodeRectangle(*n))
+	e.SetCount(n.Count())
+	e.SetLHV(largestLHV(n.GetEntries()))
+}
+
+// entryIndexOf. return index entry di parent yang child nya childPageNum, -1 kalau tidak ada.
+func entryIndexOf(parent *tree.Node, childPageNum types.BlockNum) int {
+	for i, e := range parent.GetEntries() {
+		if e.GetChild() == childPageNum {
+			return i
 		}
 	}
-
-	return e, idx
+	return -1
 }
 
 func createNodeRectangle(node tree.Node) tree.Rect {
@@ -384,463 +475,629 @@ func createNodeRectangle(node tree.Node) tree.Rect {
 	return rect
 }
 
-func (rt *Rtreed) pickSeeds(n *tree.Node) (int, int) {
-	var entryOneIDx, entryTwoIDx int
-	maxD := math.Inf(-1)
-	for i, e1 := range n.GetEntries() {
-		for j := i + 1; j < len(n.GetEntries()); j++ {
-			e2 := n.GetEntries()[j]
-			areaJ := tree.CreateRectangle(e1.GetRect(), e2.GetRect()).Area()
-			d := areaJ - e1.GetRect().Area() - e2.GetRect().Area()
-			if d > maxD {
-				maxD = d
-				entryOneIDx = i
-				entryTwoIDx = j
-			}
-		}
-	}
-	return entryOneIDx, entryTwoIDx
-}
-
-func (rt *Rtreed) splitNode(nPage *buffer.Buffer, minGroupSize int, needToUnpin *[]unpinPage) (*buffer.Buffer, *buffer.Buffer) {
+// splitNode splits the overflowing node in nPage in two groups with the tree's Splitter. the first group stays in
+// nPage, the second group is written to a new page whose children get their parent pointer updated.
+func (rt *Rtreed) splitNode(nPage *buffer.Buffer, minGroupSize int, needToUnpin *[]unpinPage) (*buffer.Buffer, *buffer.Buffer, error) {
 	n := nPage.DeserializeNode()
-	entryOneIDx, entryTwoIDx := rt.pickSeeds(n)
-	entryOne, entryTwo := n.GetEntry(entryOneIDx), n.GetEntry(entryTwoIDx)
 
-	otherEntriesOne := append(n.GetEntries()[:entryOneIDx], n.GetEntries()[entryOneIDx+1:entryTwoIDx]...)
-	otherEntriesTwo := n.GetEntries()[entryTwoIDx+1:]
-	otherEntries := append(otherEntriesOne, otherEntriesTwo...)
+	one, two := rt.splitter.Split(n.GetEntries(), minGroupSize)
+	if len(one) < minGroupSize || len(two) < minGroupSize || len(one)+len(two) != n.GetEntriesSize() {
+		return nil, nil, ErrInvalidSplit
+	}
 
 	groupOne := n
-	groupOne.SetEntries([]*tree.Entry{entryOne})
-	groupTwo := &tree.Node{}
-	groupTwo.SetParent(n.GetParent())
-	groupTwo.SetIsleaf(n.IsLeaf())
-	groupTwo.SetLevel(n.Level())
-	groupTwo.SetEntries([]*tree.Entry{entryTwo})
-	groupTwo.SetPageNum(lib.NEW_PAGE_NUM)
-
-	groupTwoUpdated, groupTwoPage, err := rt.writeNodeAndGetPage(groupTwo)
+	groupOne.SetEntries(one)
+	groupTwo := tree.NewNode(two, n.GetParent(), n.Level(), n.IsLeaf())
+
+	groupTwoUpdated, groupTwoPage, err := rt.writeNodeAndGetPage(groupTwo, needToUnpin)
 	if err != nil {
-		panic(err)
+		return nil, nil, err
 	}
 
-	if entryTwo.GetChild() != lib.NEW_PAGE_NUM {
-		entryTwoChild, entryTwoChildPage, err := rt.getNodeAndPage(entryTwo.GetChild())
+	if !groupTwoUpdated.IsLeaf() {
+		for _, e := range groupTwoUpdated.GetEntries() {
+			err = rt.setParent(e.GetChild(), groupTwoUpdated.GetPageNum(), needToUnpin)
+			if err != nil {
+				return nil, nil, err
+			}
+		}
+	} else {
+		err = rt.setLeafIDs(groupTwoUpdated.GetEntries(), groupTwoUpdated.GetPageNum())
 		if err != nil {
-			panic(err)
+			return nil, nil, err
 		}
-		entryTwoChild.SetParent(groupTwoUpdated.GetPageNum())
+	}
 
-		entryTwoChildPage.SerializeNode(entryTwoChild)
+	nPage.SerializeNode(groupOne)
+	markDirty(needToUnpin, groupOne.GetPageNum())
+	return nPage, groupTwoPage, nil
+}
 
-		*needToUnpin = append(*needToUnpin, newUnpinPage(entryTwo.GetChild(), true))
+// Delete removes the leaf entry whose payload equals obj.Data(). it returns false when no such entry exists.
+// when several objects share the payload any one of them is removed, DeleteByID removes exactly one object.
+func (rt *Rtreed) Delete(obj tree.SpatialData) (bool, error) {
+	needToUnpin := make([]unpinPage, 0, 10)
+	defer func() {
+		rt.unpinAll(needToUnpin)
+	}()
 
+	leaf, leafPage, delIDx, err := rt.findLeaf(rt.root, obj, &needToUnpin)
+	if err != nil {
+		return false, err
 	}
-	if entryOne.GetChild() != lib.NEW_PAGE_NUM {
-		entryOneChild, entryOneChildPage, err := rt.getNodeAndPage(entryOne.GetChild())
-		if err != nil {
-			panic(err)
-		}
-		entryOneChild.SetParent(groupOne.GetPageNum())
-
-		entryOneChildPage.SerializeNode(entryOneChild)
-		*needToUnpin = append(*needToUnpin, newUnpinPage(entryOne.GetChild(), true))
+	if leaf == nil {
+		return false, nil
 	}
 
-	for len(otherEntries) > 0 {
-		next := pickNext(groupOne, groupTwoUpdated, otherEntries)
-		e := otherEntries[next]
+	_, err = rt.removeEntry(leaf, leafPage, delIDx, &needToUnpin)
+	if err != nil {
+		return false, err
+	}
+	return true, nil
+}
 
-		if len(otherEntries)+len(groupOne.GetEntries()) <= minGroupSize {
-			rt.assignEntryToGroup(e, groupOne, needToUnpin)
-		} else if len(otherEntries)+len(groupTwoUpdated.GetEntries()) <= minGroupSize {
-			rt.assignEntryToGroup(e, groupTwoUpdated, needToUnpin)
-		} else {
+// DeleteByID removes the object with the given id. it returns false when no such object exists.
+func (rt *Rtreed) DeleteByID(id uint64) (bool, error) {
+	needToUnpin := make([]unpinPage, 0, 10)
+	defer func() {
+		rt.unpinAll(needToUnpin)
+	}()
 
-			gOneRect := createNodeRectangle(*groupOne)
-			gTwoRect := createNodeRectangle(*groupTwoUpdated)
-			gOneEntryRect := tree.CreateRectangle(gOneRect, e.GetRect())
-			gTwoEntryRect := tree.CreateRectangle(gTwoRect, e.GetRect())
-
-			gOneEnlargement := gOneEntryRect.Area() - gOneRect.Area()
-			gTwoEnlargement := gTwoEntryRect.Area() - gTwoRect.Area()
-			if gOneEnlargement < gTwoEnlargement {
-				rt.assignEntryToGroup(e, groupOne, needToUnpin)
-			} else if gOneEnlargement > gTwoEnlargement {
-				rt.assignEntryToGroup(e, groupTwoUpdated, needToUnpin)
-			} else if gOneRect.Area() < gTwoRect.Area() {
-				rt.assignEntryToGroup(e, groupOne, needToUnpin)
-			} else if gOneRect.Area() > gTwoRect.Area() {
-				rt.assignEntryToGroup(e, groupTwoUpdated, needToUnpin)
-			} else if len(groupOne.GetEntries()) <= len(groupTwoUpdated.GetEntries()) {
-				rt.assignEntryToGroup(e, groupOne, needToUnpin)
-			} else {
-				rt.assignEntryToGroup(e, groupTwoUpdated, needToUnpin)
-			}
-		}
-		otherEntries = append(otherEntries[:next], otherEntries[next+1:]...)
+	leaf, leafPage, delIDx, err := rt.findLeafByID(id, &needToUnpin)
+	if err != nil {
+		return false, err
+	}
+	if leaf == nil {
+		return false, nil
 	}
 
-	nPage.SerializeNode(groupOne)
+	_, err = rt.removeEntry(leaf, leafPage, delIDx, &needToUnpin)
+	if err != nil {
+		return false, err
+	}
+	return true, nil
+}
 
-	groupTwoPage.SerializeNode(groupTwoUpdated)
+// removeEntry. hapus entry ke-delIDx dari leaf, condense & shrink tree, return object yang dihapus.
+// page di needToUnpin sudah diunpin waktu return.
+func (rt *Rtreed) removeEntry(leaf *tree.Node, leafPage *buffer.Buffer, delIDx int,
+	needToUnpin *[]unpinPage) (tree.SpatialData, error) {
+	removed := leaf.GetEntry(delIDx).GetObject()
 
-	*needToUnpin = append(*needToUnpin, newUnpinPage(groupOne.GetPageNum(), true))
-	*needToUnpin = append(*needToUnpin, newUnpinPage(groupTwoUpdated.GetPageNum(), true))
-	return nPage, groupTwoPage
-}
+	leaf.SetEntry(delIDx, leaf.GetEntries()[leaf.GetEntriesSize()-1])
+	leaf.SetEntries(leaf.GetEntries()[:leaf.GetEntriesSize()-1])
+	leafPage.SerializeNode(leaf)
+	markDirty(needToUnpin, leaf.GetPageNum())
 
-func (rt *Rtreed) assignEntryToGroup(e *tree.Entry, group *tree.Node, needToUnpin *[]unpinPage) {
-	if e.GetChild() != lib.NEW_PAGE_NUM {
-		eChild, eChildPage, _ := rt.getNodeAndPage(e.GetChild())
-		var childPageNum types.BlockNum = 0
+	err := rt.setIDPage(removed.ID(), 0)
+	if err != nil {
+		return removed, err
+	}
 
-		if eChildPage != nil {
-			eChild.SetParent(group.GetPageNum())
+	orphans, err := rt.condenseTree(leaf, needToUnpin)
+	if err != nil {
+		return removed, err
+	}
 
-			eChildPage.SerializeNode(eChild)
+	rt.unpinAll(*needToUnpin)
+	*needToUnpin = (*needToUnpin)[:0]
 
-			childPageNum = eChild.GetPageNum()
+	// CT6. [Re-insert orphaned entries.] entries of eliminated nodes go back at the level they came from,
+	// so leaf entries land in leaves and subtrees keep their height
+	for _, orphan := range orphans {
+		for _, e := range orphan.GetEntries() {
+			err = rt.insert(e, orphan.Level(), newOverflow())
+			if err != nil {
+				return removed, err
+			}
 		}
+	}
 
-		*needToUnpin = append(*needToUnpin, newUnpinPage(childPageNum, true))
-
-		e.SetChild(childPageNum)
+	err = rt.shrinkTree()
+	if err != nil {
+		return removed, err
 	}
 
-	group.AppendEntry(e)
+	rt.size--
+	rt.updateMetaHeightSeize(rt.height, rt.size)
+	return removed, nil
 }
 
-func pickNext(groupOne, groupTwo *tree.Node, entries []*tree.Entry) int {
-	maxDiff := math.Inf(-1)
-	var chosenEntry int
-	gOneRect := createNodeRectangle(*groupOne)
-	gTwoRect := createNodeRectangle(*groupTwo)
+// findLeafByID. leaf yang menyimpan object dengan id & index entry nya, lewat id index. leaf nil kalau id tidak ada.
+func (rt *Rtreed) findLeafByID(id uint64, needToUnpin *[]unpinPage) (*tree.Node, *buffer.Buffer, int, error) {
+	pageNum, err := rt.lookupID(id)
+	if err != nil || pageNum == 0 {
+		return nil, nil, -1, err
+	}
 
-	for i, e := range entries {
-		d1 := tree.CreateRectangle(gOneRect, e.GetRect()).Area() - gOneRect.Area()
-		d2 := tree.CreateRectangle(gTwoRect, e.GetRect()).Area() - gTwoRect.Area()
-		d := math.Abs(d1 - d2)
-		if d > maxDiff {
-			maxDiff = d
-			chosenEntry = i
-		}
+	leaf, leafPage, err := rt.fetchNode(pageNum, needToUnpin)
+	if err != nil {
+		return nil, nil, -1, err
 	}
-	return chosenEntry
+	idx := entryIndexByID(leaf, id)
+	if !leaf.IsLeaf() || idx == -1 {
+		return nil, nil, -1, ErrCorruptIDIndex
+	}
+	return leaf, leafPage, idx, nil
 }
 
-func (rt *Rtreed) Delete(obj tree.SpatialData) bool {
-	root, err := rt.getNode(rt.root)
+// Get returns the object with the given id, ErrObjectNotFound when there is none.
+func (rt *Rtreed) Get(id uint64) (tree.SpatialData, error) {
+	pageNum, err := rt.lookupID(id)
 	if err != nil {
-		panic(err)
+		return tree.SpatialData{}, err
 	}
-
-	n := rt.findLeaf(root, obj)
-	if n == nil {
-		return false
+	if pageNum == 0 {
+		return tree.SpatialData{}, ErrObjectNotFound
 	}
 
-	delIDx := -1
-	for i, e := range n.GetEntries() {
-		eObj := e.GetObject()
-		if bytes.Compare(eObj.Data(), obj.Data()) == 0 {
-			delIDx = i
-			break
-		}
+	leaf, err := rt.getNode(pageNum)
+	if err != nil {
+		return tree.SpatialData{}, err
 	}
+	rt.unpin(pageNum, false)
 
-	if delIDx < 0 {
-		return false
+	idx := entryIndexByID(leaf, id)
+	if !leaf.IsLeaf() || idx == -1 {
+		return tree.SpatialData{}, ErrCorruptIDIndex
 	}
+	return leaf.GetEntry(idx).GetObject(), nil
+}
 
-	n.SetEntry(delIDx, n.GetEntries()[len(n.GetEntries())-1])
-	n.SetEntries(n.GetEntries()[:len(n.GetEntries())-1])
-	needToUnpin := make([]unpinPage, 0, 10)
-	rt.condenseTree(n, &needToUnpin)
-	rt.size--
-
-	rt.writeNode(n)
+// findLeaf returns the leaf holding obj and the index of its entry. pages of subtrees that do not hold obj are
+// unpinned right away, the path to the found leaf stays in needToUnpin.
+func (rt *Rtreed) findLeaf(pageNum types.BlockNum, obj tree.SpatialData,
+	needToUnpin *[]unpinPage) (*tree.Node, *buffer.Buffer, int, error) {
 
-	if !root.IsLeaf() && root.GetEntriesSize() == 1 {
-		rEntryChild, err := rt.getNode(root.GetEntries()[0].GetChild())
-		if err != nil {
-			panic(err)
-		}
-		root = rEntryChild
-		rt.writeNode(root)
+	n, nPage, err := rt.getNodeAndPage(pageNum)
+	if err != nil {
+		return nil, nil, -1, err
 	}
 
-	rt.metadata.SetHeight(root.Level())
-
-	return true
-}
-
-func (rt *Rtreed) findLeaf(n *tree.Node, obj tree.SpatialData) *tree.Node {
 	if n.IsLeaf() {
-		return n
+		for i, leafEntry := range n.GetEntries() {
+			leafObj := leafEntry.GetObject()
+			if bytes.Equal(leafObj.Data(), obj.Data()) {
+				*needToUnpin = append(*needToUnpin, newUnpinPage(pageNum, false))
+				return n, nPage, i, nil
+			}
+		}
+		rt.unpin(pageNum, false)
+		return nil, nil, -1, nil
 	}
-	for _, e := range n.GetEntries() {
 
-		if e.GetRect().ContainRect(obj.Bounds()) {
-			eChild, _ := rt.getNode(e.GetChild())
-
-			leaf := rt.findLeaf(eChild, obj)
-			if leaf == nil {
-				continue
-			}
+	for _, e := range n.GetEntries() {
+		if !e.GetRect().ContainRect(obj.Bounds()) {
+			continue
+		}
 
-			for _, leafEntry := range leaf.GetEntries() {
-				leafObj := leafEntry.GetObject()
-				if bytes.Compare(leafObj.Data(), obj.Data()) == 0 {
-					return leaf
-				}
-			}
+		leaf, leafPage, idx, err := rt.findLeaf(e.GetChild(), obj, needToUnpin)
+		if err != nil {
+			rt.unpin(pageNum, false)
+			return nil, nil, -1, err
+		}
+		if leaf != nil {
+			*needToUnpin = append(*needToUnpin, newUnpinPage(pageNum, false))
+			return leaf, leafPage, idx, nil
 		}
 	}
 
-	return nil
+	rt.unpin(pageNum, false)
+	return nil, nil, -1, nil
 }
 
-func (rt *Rtreed) condenseTree(n *tree.Node, needToUnpin *[]unpinPage) {
-	deleted := []unpinPage{}
+// condenseTree ascends from leaf n after one of its entries was removed. under-full nodes are cut out of their
+// parent and returned so their entries can be reinserted, covering rectangles and subtree counts of the remaining
+// nodes are updated up to the root.
+func (rt *Rtreed) condenseTree(n *tree.Node, needToUnpin *[]unpinPage) ([]*tree.Node, error) {
+	orphans := []*tree.Node{}
 
-	root, err := rt.getNode(rt.root)
-	if err != nil {
-		panic(err)
-	}
-
-	for n != root {
-		nParent, err := rt.getNode(n.GetParent())
+	for n.GetPageNum() != rt.root {
+		nParent, nParentPage, err := rt.fetchNode(n.GetParent(), needToUnpin)
 		if err != nil {
-			panic(err)
+			return nil, err
 		}
 
-		if n.GetEntriesSize() < rt.minEntries {
-			idx := -1
-			_, idx = rt.getNFromParentEntry(n, needToUnpin)
+		idx := entryIndexOf(nParent, n.GetPageNum())
+		if idx == -1 {
+			return nil, ErrCorruptTree
+		}
 
-			if idx == -1 {
-				panic(err)
-			}
+		if n.GetEntriesSize() < rt.minEntries {
 			l := nParent.GetEntriesSize()
 			nParent.SetEntry(idx, nParent.GetEntries()[l-1])
 			nParent.SetEntries(nParent.GetEntries()[:l-1])
 
-			deleted = append(deleted, newUnpinPage(n.GetPageNum(), true))
+			orphans = append(orphans, n)
 		} else {
-			en, idx := rt.getNFromParentEntry(n, needToUnpin)
-			prevRect := en.GetRect()
-			en.SetRect(createNodeRectangle(*n))
-
-			nParent.SetEntry(idx, en)
-			if en.GetRect().Equal(prevRect) {
-				break
-			}
+			updateParentEntry(nParent.GetEntry(idx), n)
 		}
-		rt.writeNode(nParent)
+		nParentPage.SerializeNode(nParent)
+		markDirty(needToUnpin, nParent.GetPageNum())
 		n = nParent
 	}
 
-	for i := len(deleted) - 1; i >= 0; i-- {
-		n := deleted[i]
-		nNode, err := rt.getNode(n.getPageNum())
+	return orphans, nil
+}
+
+// shrinkTree. D4. [Shorten tree.] If the root node has only one child after the tree has been adjusted,
+// make the child the new root.
+func (rt *Rtreed) shrinkTree() error {
+	for {
+		root, err := rt.getNode(rt.root)
 		if err != nil {
-			panic(err)
+			return err
+		}
+		rt.unpin(rt.root, false)
+
+		if root.IsLeaf() || root.GetEntriesSize() != 1 {
+			return nil
 		}
 
-		e := tree.NewEntry(createNodeRectangle(*nNode), n.getPageNum(), tree.SpatialData{})
-		rt.insert(e, nNode.Level()+1)
+		needToUnpin := make([]unpinPage, 0, 1)
+		child, childPage, err := rt.fetchNode(root.GetEntry(0).GetChild(), &needToUnpin)
+		if err != nil {
+			return err
+		}
+		child.SetParent(0)
+		childPage.SerializeNode(child)
+		markDirty(&needToUnpin, child.GetPageNum())
+		rt.unpinAll(needToUnpin)
+
+		rt.root = child.GetPageNum()
+		rt.upateMetaRoot(rt.root)
+		rt.height--
 	}
 }
 
-func (rt *Rtreed) NearestNeighbors(k int, p tree.Point) []tree.SpatialData {
+// NearestNeighbors returns the k objects closest to p, nearest first, each with its distance (see Result).
+// it returns fewer than k results when the tree holds fewer objects, or fewer matching
+// WithFilter and WithMaxDistance, and none when k <= 0. p must have as many axes as the tree, ErrDimMismatch
+// otherwise.
+func (rt *Rtreed) NearestNeighbors(k int, p tree.Point, opts ...SearchOption) ([]Result, error) {
+	return rt.nearestNeighbors(k, rt.Nearest(p, opts...))
+}
 
-	nearestListsPQ := priorityQueue[tree.SpatialData]{}
+func (rt *Rtreed) nearestNeighbors(k int, it *NearestIterator) ([]Result, error) {
+	if k <= 0 {
+		return nil, nil
+	}
+	// k dari caller bisa jauh lebih besar dari isi tree
+	results := make([]Result, 0, min(k, int(rt.size)))
+	for len(results) < k {
+		res, ok, err := it.NextNearest()
+		if err != nil {
+			return nil, err
+		}
+		if !ok {
+			break
+		}
+		results = append(results, res)
+	}
+	return results, nil
+}
+
+// Update replaces the object whose payload equals obj.Data() with newObj, which keeps the replaced object's id.
+// it returns ErrObjectNotFound when obj is not in the tree.
+func (rt *Rtreed) Update(obj tree.SpatialData, newObj tree.SpatialData) error {
+	if err := rt.checkObject(newObj); err != nil {
+		return err
+	}
 
-	root, err := rt.getNode(rt.root)
+	needToUnpin := make([]unpinPage, 0, 10)
+	defer func() {
+		rt.unpinAll(needToUnpin)
+	}()
+
+	leaf, leafPage, idx, err := rt.findLeaf(rt.root, obj, &needToUnpin)
 	if err != nil {
-		panic(err)
+		return err
+	}
+	if leaf == nil {
+		return ErrObjectNotFound
 	}
+	return rt.replaceEntry(leaf, leafPage, idx, newObj, &needToUnpin)
+}
 
-	rt.nearestNeighbors(k, p, root, &nearestListsPQ)
+// UpdateByID replaces the object with the given id by newObj, keeping the id. it returns ErrObjectNotFound when
+// there is no such object.
+func (rt *Rtreed) UpdateByID(id uint64, newObj tree.SpatialData) error {
+	if err := rt.checkObject(newObj); err != nil {
+		return err
+	}
 
-	nearestLists := []tree.SpatialData{}
-	for nearestListsPQ.Len() > 0 {
-		nearestLists = append(nearestLists, heap.Pop(&nearestListsPQ).(*priorityQueueNode[tree.SpatialData]).item)
+	needToUnpin := make([]unpinPage, 0, 10)
+	defer func() {
+		rt.unpinAll(needToUnpin)
+	}()
+
+	leaf, leafPage, idx, err := rt.findLeafByID(id, &needToUnpin)
+	if err != nil {
+		return err
+	}
+	if leaf == nil {
+		return ErrObjectNotFound
 	}
-	nearestLists = reverseG(nearestLists)
-	return nearestLists
+	return rt.replaceEntry(leaf, leafPage, idx, newObj, &needToUnpin)
 }
 
-func reverseG[T any](arr []T) (result []T) {
-	for i, j := 0, len(arr)-1; i < j; i, j = i+1, j-1 {
-		arr[i], arr[j] = arr[j], arr[i]
+// Move moves the object with the given id to p, keeping its payload, id, time range and the shape of its extent
+// (see tree.SpatialData.MoveTo). it returns ErrObjectNotFound when there is no such object.
+func (rt *Rtreed) Move(id uint64, p tree.Point) error {
+	if p.Dims() != rt.dim {
+		return ErrDimMismatch
 	}
-	return arr
+	obj, err := rt.Get(id)
+	if err != nil {
+		return err
+	}
+	return rt.UpdateByID(id, obj.MoveTo(p))
 }
 
-type activeBranch struct {
-	entry *tree.Entry
-	Dist  float64
+// replaceEntry. hapus entry ke-idx dari leaf & insert newObj dengan id object yang dihapus.
+func (rt *Rtreed) replaceEntry(leaf *tree.Node, leafPage *buffer.Buffer, idx int, newObj tree.SpatialData,
+	needToUnpin *[]unpinPage) error {
+	removed, err := rt.removeEntry(leaf, leafPage, idx, needToUnpin)
+	if err != nil {
+		return err
+	}
+	return rt.insertObject(newObj.WithID(removed.ID()))
 }
 
-func sortActiveBranchLists(p tree.Point, entries []*tree.Entry) ([]*tree.Entry, []float64) {
+// SearchWithinRadius returns the objects within radius of p, each with its distance to p (see Result).
+// without WithExactDistance the results are everything inside the bounding box of the circle. p must have as many
+// axes as the tree, ErrDimMismatch otherwise.
+func (rt *Rtreed) SearchWithinRadius(p tree.Point, radius float64, opts ...SearchOption) ([]Result, error) {
+	if p.Dims() != rt.dim {
+		return nil, ErrDimMismatch
+	}
+	cfg := newSearchConfig(opts)
 
-	sorted := make([]activeBranch, len(entries))
-	for i := 0; i < len(entries); i++ {
-		sorted[i] = activeBranch{entries[i], p.MinDist(entries[i].GetRect())}
+	results := make([]Result, 0, 100)
+	for _, bound := range rt.metric.expand(p.ToRect(0), radius) {
+		var err error
+		results, err = rt.searchStack(overlapFilter(bound), cfg, results, radiusAccept(rt.metric, p, radius, cfg))
+		if err != nil {
+			rt.Release(results)
+			return nil, err
+		}
 	}
-	sort.Slice(sorted, func(i, j int) bool {
-		return sorted[i].Dist < sorted[j].Dist
-	})
 
-	maxDist := sorted[len(sorted)-1].Dist
+	if cfg.sortByDistance {
+		sortResultsByDistance(results)
+	}
+	return results, nil
+}
 
-	removeIDx := len(sorted)
-	for i := range sorted {
-		if sorted[i].Dist > maxDist {
-			removeIDx = i
-			break
+// Search returns the objects whose extent satisfies pred against rect. rect must have as many axes as the tree,
+// ErrDimMismatch otherwise.
+func (rt *Rtreed) Search(rect tree.Rect, pred Predicate, opts ...SearchOption) ([]Result, error) {
+	if rect.Dims() != rt.dim {
+		return nil, ErrDimMismatch
+	}
+	cfg := newSearchConfig(opts)
+
+	results, err := rt.searchStack(pred.filter(rect), cfg, make([]Result, 0, 100), pred.accept(rect))
+	if err != nil {
+		rt.Release(results)
+		return nil, err
+	}
+	return results, nil
+}
+
+// Count returns the number of objects intersecting rect without reading them: subtrees whose MBR lies inside rect
+// add their stored count, only subtrees crossing rect's border are descended. rect must have as many axes as the
+// tree, ErrDimMismatch otherwise.
+func (rt *Rtreed) Count(rect tree.Rect) (int, error) {
+	if rect.Dims() != rt.dim {
+		return 0, ErrDimMismatch
+	}
+	count := 0
+	stack := []types.BlockNum{rt.root}
+	for len(stack) > 0 {
+		nPageNum := stack[len(stack)-1]
+		stack = stack[:len(stack)-1]
+
+		node, err := rt.getNodeByte(nPageNum)
+		if err != nil {
+			return 0, err
 		}
+		isLeaf := node.IsLeaf()
+		node.ForEntries(func(e tree.Entry) {
+			switch {
+			case rect.ContainRect(e.GetRect()):
+				count += e.GetCount()
+			case !rect.Overlaps(e.GetRect()):
+			case !isLeaf:
+				stack = append(stack, e.GetChild())
+			default:
+				obj := e.GetObject()
+				if rect.Overlaps(obj.Extent()) {
+					count++
+				}
+			}
+		})
+		rt.unpin(nPageNum, false)
 	}
+	return count, nil
+}
 
-	sorted = sorted[:removeIDx]
-	sortedEntries := make([]*tree.Entry, len(sorted))
-	for i, e := range sorted {
-		sortedEntries[i] = e.entry
+// SearchPolygon returns the objects whose location is inside poly. nodes are pruned with an MBR vs polygon
+// intersection test, leaf objects with an exact point in polygon test. it needs a lat/lon tree, ErrNotGeographic
+// otherwise.
+func (rt *Rtreed) SearchPolygon(poly tree.Polygon, opts ...SearchOption) ([]Result, error) {
+	if rt.dim != 2 {
+		return nil, ErrNotGeographic
 	}
-	dists := make([]float64, len(sorted))
-	for i, e := range sorted {
-		dists[i] = e.Dist
+	cfg := newSearchConfig(opts)
+
+	results, err := rt.searchStack(polygonFilter(poly), cfg, make([]Result, 0, 100), polygonAccept(poly))
+	if err != nil {
+		rt.Release(results)
+		return nil, err
 	}
+	return results, nil
+}
 
-	return sortedEntries, dists
+func polygonFilter(poly tree.Polygon) entryFilter {
+	return entryFilter{node: poly.IntersectsRect, leaf: poly.Bounds().Overlaps}
 }
 
-func insertToNearestLists(nearestLists *priorityQueue[tree.SpatialData], obj tree.SpatialData, dist float64, k int) {
-	if nearestLists.Len() < k {
-		heap.Push(nearestLists, &priorityQueueNode[tree.SpatialData]{rank: dist, item: obj})
-	} else if dist < (*nearestLists)[0].rank {
-		heap.Pop(nearestLists)
-		heap.Push(nearestLists, &priorityQueueNode[tree.SpatialData]{rank: dist, item: obj})
+func polygonAccept(poly tree.Polygon) func(obj tree.SpatialData) (Result, bool) {
+	return func(obj tree.SpatialData) (Result, bool) {
+		if !poly.ContainsPoint(obj.Location()) {
+			return Result{}, false
+		}
+		return Result{SpatialData: obj}, true
 	}
 }
 
-func (rt *Rtreed) nearestNeighbors(k int, q tree.Point, n *tree.Node, nearestLists *priorityQueue[tree.SpatialData]) {
-	var nearestListMaxDist float64 = math.Inf(1)
+// SearchAlongPath returns the objects whose location is within distance km of the polyline path, each with its
+// great-circle distance in km to the nearest segment. segments are great-circle arcs between consecutive points, a
+// single point is searched like SearchWithinRadius. nodes outside the bounding boxes of every segment expanded by
+// distance are pruned, leaf objects are filtered by their exact cross-track distance. it needs a lat/lon tree,
+// ErrNotGeographic otherwise, with lat/lon points, ErrDimMismatch otherwise.
+func (rt *Rtreed) SearchAlongPath(path []tree.Point, distance float64, opts ...SearchOption) ([]Result, error) {
+	if rt.dim != 2 {
+		return nil, ErrNotGeographic
+	}
+	if !pathDims(path, rt.dim) {
+		return nil, ErrDimMismatch
+	}
+	cfg := newSearchConfig(opts)
 
-	pq := *nearestLists
-	if nearestLists.Len() < k && nearestLists.Len() > 0 {
-		nearestListMaxDist = pq[0].rank
+	segments := newPathSegments(path, distance)
+	results, err := rt.searchStack(segments.filter(), cfg, make([]Result, 0, 100), segments.accept(distance))
+	if err != nil {
+		rt.Release(results)
+		return nil, err
 	}
 
-	if n.IsLeaf() {
-		for _, e := range n.GetEntries() {
-			eObj := e.GetObject()
-			dist := haversineDistance(q.Lat, q.Lon, eObj.Location().Lat, eObj.Location().Lon)
-			if dist < nearestListMaxDist {
-				insertToNearestLists(nearestLists, e.GetObject(), dist, k)
-			}
-		}
+	if cfg.sortByDistance {
+		sortResultsByDistance(results)
+	}
+	return results, nil
+}
 
-	} else {
-		activeBranchLists := make([]*tree.Entry, n.GetEntriesSize())
-		copy(activeBranchLists, n.GetEntries())
-		activeBranchLists, dists := sortActiveBranchLists(q, activeBranchLists)
+type pathSegment struct {
+	a, b   tree.Point
+	bounds []tree.Rect
+}
 
-		for i, e := range activeBranchLists {
-			eChild, _ := rt.getNode(e.GetChild())
+type pathSegments []pathSegment
 
-			if dists[i] < nearestListMaxDist {
-				rt.nearestNeighbors(k, q, eChild, nearestLists)
-			} else {
-				break // activeBranchList udah sorted
-			}
+// pathDims. semua titik path punya dims axis.
+func pathDims(path []tree.Point, dims int) bool {
+	for _, p := range path {
+		if p.Dims() != dims {
+			return false
 		}
-
 	}
+	return true
 }
 
-func (rt *Rtreed) Update(obj tree.SpatialData, newObj tree.SpatialData) error {
-	found := rt.Delete(obj)
-	if !found {
-		return errors.New("object not found")
+func newPathSegments(path []tree.Point, distance float64) pathSegments {
+	if len(path) == 1 {
+		return pathSegments{{a: path[0], b: path[0], bounds: segmentBounds(path[0], path[0], distance)}}
 	}
-	rt.Insert(newObj)
-	return nil
+	segments := make(pathSegments, 0, len(path))
+	for i := 0; i+1 < len(path); i++ {
+		segments = append(segments, pathSegment{a: path[i], b: path[i+1], bounds: segmentBounds(path[i], path[i+1], distance)})
+	}
+	return segments
 }
 
-func (rt *Rtreed) SearchWithinRadius(p tree.Point, radius float64) []tree.SpatialData {
+func (seg pathSegment) overlaps(r tree.Rect) bool {
+	for _, bound := range seg.bounds {
+		if bound.Overlaps(r) {
+			return true
+		}
+	}
+	return false
+}
 
-	upperRightLat, upperRightLon := getDestinationPoint(p.Lat, p.Lon, 45, radius)
-	lowerLeftLat, lowerLeftLon := getDestinationPoint(p.Lat, p.Lon, 225, radius)
+// overlaps. cek r overlap dengan bounding box salah satu segment.
+func (segments pathSegments) overlaps(r tree.Rect) bool {
+	for _, seg := range segments {
+		if seg.overlaps(r) {
+			return true
+		}
+	}
+	return false
+}
 
-	bound := tree.NewRectFromBounds(lowerLeftLat, lowerLeftLon, upperRightLat, upperRightLon)
-	return rt.searchWithinBoundStack(bound)
+func (segments pathSegments) filter() entryFilter {
+	return entryFilter{node: segments.overlaps, leaf: segments.overlaps}
 }
 
-func (rt *Rtreed) searchWithinBound(bound tree.Rect) []tree.SpatialData {
-	results := make([]tree.SpatialData, 0, 100)
-	needToUnpin := make([]unpinPage, 0, 20)
-	root, err := rt.getNodeByte(rt.root)
-	if err != nil {
-		panic(err)
+// accept. jarak object ke segment terdekat, cuma segment yang bounding box nya overlap dengan object yang dihitung.
+func (segments pathSegments) accept(distance float64) func(obj tree.SpatialData) (Result, bool) {
+	return func(obj tree.SpatialData) (Result, bool) {
+		loc := obj.Location()
+		objRect := loc.ToRect(0)
+
+		minDist := math.Inf(1)
+		for _, seg := range segments {
+			if !seg.overlaps(objRect) {
+				continue
+			}
+			minDist = math.Min(minDist, pointSegmentDistance(loc, seg.a, seg.b))
+		}
+		if minDist > distance {
+			return Result{}, false
+		}
+		return Result{SpatialData: obj, Distance: minDist}, true
 	}
-	needToUnpin = append(needToUnpin, newUnpinPage(rt.root, false))
+}
 
-	results = rt.search(root, bound, results, &needToUnpin)
-	for _, p := range needToUnpin {
-		blockId := disk.NewBlockID(lib.PAGE_FILE_NAME, int(p.getPageNum()))
-		rt.bufferPoolManager.UnpinPage(blockId, p.getIsDirty())
+// radiusAccept. hitung jarak object (extent nya) ke p, buang yang lebih jauh dari radius kalau exact.
+func radiusAccept(m metric, p tree.Point, radius float64, cfg searchConfig) func(obj tree.SpatialData) (Result, bool) {
+	return func(obj tree.SpatialData) (Result, bool) {
+		dist := objectDistance(m, p, obj)
+		if cfg.exact && dist > radius {
+			return Result{}, false
+		}
+		return Result{SpatialData: obj, Distance: dist}, true
 	}
-	return results
 }
 
-func (rt *Rtreed) search(node *disk.NodeByte, bound tree.Rect,
-	results []tree.SpatialData, needToUnpin *[]unpinPage) []tree.SpatialData {
+// searchStack collects the leaf objects passing filter, see walk. accept turns each leaf object passing filter into a result or
+// rejects it. leaf pages that produced a WithZeroCopy result stay pinned until Release.
+func (rt *Rtreed) searchStack(filter entryFilter, cfg searchConfig, results []Result,
+	accept func(obj tree.SpatialData) (Result, bool)) ([]Result, error) {
+	filter = rt.timeFilter(filter, cfg)
 
-	if !node.IsLeaf() {
-		node.ForEntriesOverlaps(bound, func(child types.BlockNum) {
-			// S1. [Search subtrees.] If T is not a leaf,
-			// check each entry E to determine
-			// whether E.I Overlaps S. For all overlapping entries, invoke Search on the tree
-			// whose root node is pointed to by E.p
-			eChildNode, err := rt.getNodeByte(child)
-			*needToUnpin = append(*needToUnpin, newUnpinPage(child, false))
-			if err != nil {
-				panic(err)
-			}
-			results = rt.search(eChildNode, bound, results, needToUnpin)
-		}, nil)
-	} else {
-
-		node.ForEntriesOverlaps(bound, nil, func(lat, lon float64, data []byte) {
+	err := rt.walk(filter, func(nPageNum types.BlockNum, node *disk.NodeByte) (bool, bool) {
+		keepPinned := false
+		node.ForEntriesMatch(filter.leaf, nil, func(obj tree.SpatialData) {
 			// S2. [Search leaf node.] If T is a leaf, check
 			// all entries E to determine whether E.I
 			// Overlaps S. If so, E is a qualifying
 			// record
-			obj := tree.NewSpatialData(tree.NewPoint(lat, lon), data)
-			results = append(results, obj)
+			if !cfg.keep(obj) {
+				return
+			}
+			res, ok := accept(obj)
+			if !ok {
+				return
+			}
+			res.SetData(cfg.payload(obj.Data()))
+			if cfg.zeroCopy && !keepPinned {
+				res.page = nPageNum
+				res.ownsPin = true
+				keepPinned = true
+			}
+			results = append(results, res)
 		})
-	}
-
-	return results
-}
-
-func (rt *Rtreed) searchWithinBoundStack(bound tree.Rect) []tree.SpatialData {
-	results := make([]tree.SpatialData, 0, 100)
-	needToUnpin := make([]unpinPage, 0, 20)
-
-	needToUnpin = append(needToUnpin, newUnpinPage(rt.root, false))
-
-	results = rt.searchStack(bound, needToUnpin)
-
-	return results
+		return keepPinned, false
+	})
+	return results, err
 }
 
-func (rt *Rtreed) searchStack(bound tree.Rect, needToUnpin []unpinPage) []tree.SpatialData {
-	results := make([]tree.SpatialData, 0, 100)
+// walk traverses the subtrees passing filter.node with an explicit stack and calls onLeaf for every leaf page it
+// reaches. only the page being visited is pinned: internal pages are unpinned as soon as their entries are read,
+// leaf pages after onLeaf returns unless it asks to keep them pinned. onLeaf returns stop to end the traversal.
+func (rt *Rtreed) walk(filter entryFilter, onLeaf func(pageNum types.BlockNum, node *disk.NodeByte) (keepPinned, stop bool)) error {
 	stack := make([]types.BlockNum, 0, 16)
 	stack = append(stack, rt.root)
 
@@ -850,35 +1107,29 @@ func (rt *Rtreed) searchStack(bound tree.Rect, needToUnpin []unpinPage) []tree.S
 
 		node, err := rt.getNodeByte(nPageNum)
 		if err != nil {
-			panic(err)
+			return err
 		}
-		needToUnpin = append(needToUnpin, newUnpinPage(nPageNum, false))
 
 		if !node.IsLeaf() {
-			node.ForEntriesOverlaps(bound, func(child types.BlockNum) {
+			node.ForEntriesMatch(filter.node, func(child types.BlockNum) {
 				// S1. [Search subtrees.] If T is not a leaf,
 				// check each entry E to determine
 				// whether E.I Overlaps S. For all overlapping entries, invoke Search on the tree
 				// whose root node is pointed to by E.p
 				stack = append(stack, child)
 			}, nil)
-		} else {
-
-			node.ForEntriesOverlaps(bound, nil, func(lat, lon float64, data []byte) {
-				// S2. [Search leaf node.] If T is a leaf, check
-				// all entries E to determine whether E.I
-				// Overlaps S. If so, E is a qualifying
-				// record
-				obj := tree.NewSpatialData(tree.NewPoint(lat, lon), data)
-				results = append(results, obj)
-			})
+			rt.unpin(nPageNum, false)
+			continue
 		}
-	}
 
-	for _, p := range needToUnpin {
-		blockId := disk.NewBlockID(lib.PAGE_FILE_NAME, int(p.getPageNum()))
-		rt.bufferPoolManager.UnpinPage(blockId, p.getIsDirty())
+		keepPinned, stop := onLeaf(nPageNum, node)
+		if !keepPinned {
+			rt.unpin(nPageNum, false)
+		}
+		if stop {
+			return nil
+		}
 	}
 
-	return results
+	return nil
 }
@@ -0,0 +1,293 @@
+package index
+
+import (
+	"math"
+	"sort"
+
+	"github.com/lintang-b-s/rtreed/lib/tree"
+)
+
+// Splitter divides the entries of an overflowing node, MaxEntries+1 of them, in two groups of at least minEntries
+// entries each. the first group stays in the node's page, the second one goes to a new page.
+type Splitter interface {
+	Split(entries []*tree.Entry, minEntries int) (groupOne, groupTwo []*tree.Entry)
+}
+
+// LinearSplit is Guttman's linear split: the seeds are the pair of entries most separated along one axis, relative to
+// the width of the node on that axis, the other entries go to the group needing the least enlargement in input order.
+// cheapest to compute, the groups overlap the most.
+type LinearSplit struct{}
+
+// QuadraticSplit is Guttman's quadratic split: the seeds are the pair wasting the most area when put together, then
+// the entry with the strongest preference for one group goes next. the default of StrategyGuttman.
+type QuadraticSplit struct{}
+
+// RStarSplit is the R*-tree topological split: along the axis with the smallest total margin over all distributions
+// of the entries sorted on that axis, the distribution with the least overlap between the groups. the default of
+// StrategyRStar.
+type RStarSplit struct{}
+
+// AngTanSplit is Ang & Tan's linear split: on every axis each entry goes to the group of the node side it is closer
+// to, the axis with the most even distribution wins, ties broken by the least overlap and then the least total area.
+// linear like LinearSplit, with groups that overlap about as little as RStarSplit's but tend to be elongated.
+type AngTanSplit struct{}
+
+// HilbertSplit cuts the entries sorted by their largest Hilbert value in two halves. it ignores the rectangles, so it
+// only makes sense for trees whose entries follow the Hilbert curve. the default of StrategyHilbert.
+type HilbertSplit struct{}
+
+func (LinearSplit) Split(entries []*tree.Entry, minEntries int) ([]*tree.Entry, []*tree.Entry) {
+	entryOneIDx, entryTwoIDx := linearPickSeeds(entries)
+	return distribute(entries, entryOneIDx, entryTwoIDx, minEntries, func(_, _ *tree.Node, _ []*tree.Entry) int {
+		return 0
+	})
+}
+
+func (QuadraticSplit) Split(entries []*tree.Entry, minEntries int) ([]*tree.Entry, []*tree.Entry) {
+	entryOneIDx, entryTwoIDx := pickSeeds(entries)
+	return distribute(entries, entryOneIDx, entryTwoIDx, minEntries, pickNext)
+}
+
+// linearPickSeeds. LPS1-LPS3: di tiap axis, entry dengan lower bound tertinggi & entry dengan upper bound terendah,
+// jaraknya dinormalisasi dengan lebar semua entry di axis itu. pasangan yang paling terpisah jadi seed.
+func linearPickSeeds(entries []*tree.Entry) (int, int) {
+	entryOneIDx, entryTwoIDx := 0, 1
+	maxSeparation := math.Inf(-1)
+	for axis := 0; axis < entries[0].GetRect().Dims(); axis++ {
+		highestLow, lowestHigh := 0, 0
+		lower, upper := math.Inf(1), math.Inf(-1)
+		for i, e := range entries {
+			r := e.GetRect()
+			if r.Min(axis) > entries[highestLow].GetRect().Min(axis) {
+				highestLow = i
+			}
+			if r.Max(axis) < entries[lowestHigh].GetRect().Max(axis) {
+				lowestHigh = i
+			}
+			lower, upper = math.Min(lower, r.Min(axis)), math.Max(upper, r.Max(axis))
+		}
+		if highestLow == lowestHigh {
+			continue
+		}
+
+		separation := entries[highestLow].GetRect().Min(axis) - entries[lowestHigh].GetRect().Max(axis)
+		if width := upper - lower; width > 0 {
+			separation /= width
+		}
+		if separation > maxSeparation {
+			maxSeparation = separation
+			entryOneIDx, entryTwoIDx = lowestHigh, highestLow
+		}
+	}
+	return entryOneIDx, entryTwoIDx
+}
+
+func pickSeeds(entries []*tree.Entry) (int, int) {
+	var entryOneIDx, entryTwoIDx int
+	maxD := math.Inf(-1)
+	for i, e1 := range entries {
+		for j := i + 1; j < len(entries); j++ {
+			e2 := entries[j]
+			areaJ := tree.CreateRectangle(e1.GetRect(), e2.GetRect()).Area()
+			d := areaJ - e1.GetRect().Area() - e2.GetRect().Area()
+			if d > maxD {
+				maxD = d
+				entryOneIDx = i
+				entryTwoIDx = j
+			}
+		}
+	}
+	return entryOneIDx, entryTwoIDx
+}
+
+// distribute. split Guttman setelah seed dipilih: entry sisa (urutan dari pickNext) masuk ke group yang enlargement
+// nya paling kecil, seri dipecah dengan area lalu jumlah entry. tiap group minimal minGroupSize entry.
+func distribute(entries []*tree.Entry, entryOneIDx, entryTwoIDx, minGroupSize int,
+	pickNext func(groupOne, groupTwo *tree.Node, entries []*tree.Entry) int) ([]*tree.Entry, []*tree.Entry) {
+	entryOne, entryTwo := entries[entryOneIDx], entries[entryTwoIDx]
+
+	otherEntries := make([]*tree.Entry, 0, len(entries)-2)
+	for i, e := range entries {
+		if i != entryOneIDx && i != entryTwoIDx {
+			otherEntries = append(otherEntries, e)
+		}
+	}
+
+	groupOne := tree.NewNode([]*tree.Entry{entryOne}, 0, 0, false)
+	groupTwo := tree.NewNode([]*tree.Entry{entryTwo}, 0, 0, false)
+
+	for len(otherEntries) > 0 {
+		next := pickNext(groupOne, groupTwo, otherEntries)
+		e := otherEntries[next]
+
+		if len(otherEntries)+len(groupOne.GetEntries()) <= minGroupSize {
+			groupOne.AppendEntry(e)
+		} else if len(otherEntries)+len(groupTwo.GetEntries()) <= minGroupSize {
+			groupTwo.AppendEntry(e)
+		} else {
+
+			gOneRect := createNodeRectangle(*groupOne)
+			gTwoRect := createNodeRectangle(*groupTwo)
+			gOneEntryRect := tree.CreateRectangle(gOneRect, e.GetRect())
+			gTwoEntryRect := tree.CreateRectangle(gTwoRect, e.GetRect())
+
+			gOneEnlargement := gOneEntryRect.Area() - gOneRect.Area()
+			gTwoEnlargement := gTwoEntryRect.Area() - gTwoRect.Area()
+			if gOneEnlargement < gTwoEnlargement {
+				groupOne.AppendEntry(e)
+			} else if gOneEnlargement > gTwoEnlargement {
+				groupTwo.AppendEntry(e)
+			} else if gOneRect.Area() < gTwoRect.Area() {
+				groupOne.AppendEntry(e)
+			} else if gOneRect.Area() > gTwoRect.Area() {
+				groupTwo.AppendEntry(e)
+			} else if len(groupOne.GetEntries()) <= len(groupTwo.GetEntries()) {
+				groupOne.AppendEntry(e)
+			} else {
+				groupTwo.AppendEntry(e)
+			}
+		}
+		otherEntries = append(otherEntries[:next], otherEntries[next+1:]...)
+	}
+	return groupOne.GetEntries(), groupTwo.GetEntries()
+}
+
+func pickNext(groupOne, groupTwo *tree.Node, entries []*tree.Entry) int {
+	maxDiff := math.Inf(-1)
+	var chosenEntry int
+	gOneRect := createNodeRectangle(*groupOne)
+	gTwoRect := createNodeRectangle(*groupTwo)
+
+	for i, e := range entries {
+		d1 := tree.CreateRectangle(gOneRect, e.GetRect()).Area() - gOneRect.Area()
+		d2 := tree.CreateRectangle(gTwoRect, e.GetRect()).Area() - gTwoRect.Area()
+		d := math.Abs(d1 - d2)
+		if d > maxDiff {
+			maxDiff = d
+			chosenEntry = i
+		}
+	}
+	return chosenEntry
+}
+
+func (RStarSplit) Split(entries []*tree.Entry, minEntries int) ([]*tree.Entry, []*tree.Entry) {
+	// ChooseSplitAxis
+	bestAxis, bestMargin := 0, math.Inf(1)
+	for axis := 0; axis < entries[0].GetRect().Dims(); axis++ {
+		margin := 0.0
+		for _, sorted := range sortByAxis(entries, axis) {
+			prefix, suffix := groupRects(sorted)
+			for k := minEntries; k <= len(sorted)-minEntries; k++ {
+				margin += rectMargin(prefix[k-1]) + rectMargin(suffix[k])
+			}
+		}
+		if margin < bestMargin {
+			bestAxis, bestMargin = axis, margin
+		}
+	}
+
+	// ChooseSplitIndex
+	var groupOne, groupTwo []*tree.Entry
+	bestOverlap, bestArea := math.Inf(1), math.Inf(1)
+	for _, sorted := range sortByAxis(entries, bestAxis) {
+		prefix, suffix := groupRects(sorted)
+		for k := minEntries; k <= len(sorted)-minEntries; k++ {
+			overlap := overlapArea(prefix[k-1], suffix[k])
+			area := prefix[k-1].Area() + suffix[k].Area()
+			if overlap < bestOverlap || (overlap == bestOverlap && area < bestArea) {
+				bestOverlap, bestArea = overlap, area
+				groupOne, groupTwo = sorted[:k], sorted[k:]
+			}
+		}
+	}
+	return groupOne, groupTwo
+}
+
+// sortByAxis. copy entries urut lower bound axis, & urut upper bound axis.
+func sortByAxis(entries []*tree.Entry, axis int) [2][]*tree.Entry {
+	byLower := append([]*tree.Entry(nil), entries...)
+	sort.SliceStable(byLower, func(i, j int) bool {
+		ri, rj := byLower[i].GetRect(), byLower[j].GetRect()
+		return ri.Min(axis) < rj.Min(axis) || (ri.Min(axis) == rj.Min(axis) && ri.Max(axis) < rj.Max(axis))
+	})
+	byUpper := append([]*tree.Entry(nil), entries...)
+	sort.SliceStable(byUpper, func(i, j int) bool {
+		ri, rj := byUpper[i].GetRect(), byUpper[j].GetRect()
+		return ri.Max(axis) < rj.Max(axis) || (ri.Max(axis) == rj.Max(axis) && ri.Min(axis) < rj.Min(axis))
+	})
+	return [2][]*tree.Entry{byLower, byUpper}
+}
+
+// groupRects. prefix[i] = MBR sorted[:i+1], suffix[i] = MBR sorted[i:].
+func groupRects(sorted []*tree.Entry) ([]tree.Rect, []tree.Rect) {
+	prefix := make([]tree.Rect, len(sorted))
+	suffix := make([]tree.Rect, len(sorted))
+	prefix[0] = sorted[0].GetRect()
+	for i := 1; i < len(sorted); i++ {
+		prefix[i] = tree.CreateRectangle(prefix[i-1], sorted[i].GetRect())
+	}
+	suffix[len(sorted)-1] = sorted[len(sorted)-1].GetRect()
+	for i := len(sorted) - 2; i >= 0; i-- {
+		suffix[i] = tree.CreateRectangle(sorted[i].GetRect(), suffix[i+1])
+	}
+	return prefix, suffix
+}
+
+// rectMargin. jumlah panjang sisi r di tiap axis.
+func rectMargin(r tree.Rect) float64 {
+	margin := 0.0
+	for i := 0; i < r.Dims(); i++ {
+		margin += r.Max(i) - r.Min(i)
+	}
+	return margin
+}
+
+func (AngTanSplit) Split(entries []*tree.Entry, minEntries int) ([]*tree.Entry, []*tree.Entry) {
+	bound := createNodeRectangle(*tree.NewNode(entries, 0, 0, false))
+
+	var groupOne, groupTwo []*tree.Entry
+	bestBalance, bestOverlap, bestArea := len(entries)+1, math.Inf(1), math.Inf(1)
+	for axis := 0; axis < bound.Dims(); axis++ {
+		var left, right []*tree.Entry
+		for _, e := range entries {
+			r := e.GetRect()
+			if r.Min(axis)-bound.Min(axis) < bound.Max(axis)-r.Max(axis) {
+				left = append(left, e)
+			} else {
+				right = append(right, e)
+			}
+		}
+		balance := max(len(left), len(right))
+
+		left, right = fillGroups(left, right, axis, minEntries)
+		leftRect := createNodeRectangle(*tree.NewNode(left, 0, 0, false))
+		rightRect := createNodeRectangle(*tree.NewNode(right, 0, 0, false))
+		overlap, area := overlapArea(leftRect, rightRect), leftRect.Area()+rightRect.Area()
+
+		if balance < bestBalance || (balance == bestBalance && (overlap < bestOverlap ||
+			(overlap == bestOverlap && area < bestArea))) {
+			bestBalance, bestOverlap, bestArea = balance, overlap, area
+			groupOne, groupTwo = left, right
+		}
+	}
+	return groupOne, groupTwo
+}
+
+// fillGroups. pembagian Ang-Tan tidak menjamin jumlah minimal entry. group yang kurang dari minGroupSize diisi dari
+// group lain, mulai dari entry yang paling dekat ke sisi group tsb di axis.
+func fillGroups(left, right []*tree.Entry, axis, minGroupSize int) ([]*tree.Entry, []*tree.Entry) {
+	if k := minGroupSize - len(left); k > 0 {
+		sort.SliceStable(right, func(i, j int) bool { return right[i].GetRect().Min(axis) < right[j].GetRect().Min(axis) })
+		left, right = append(left, right[:k]...), right[k:]
+	} else if k := minGroupSize - len(right); k > 0 {
+		sort.SliceStable(left, func(i, j int) bool { return left[i].GetRect().Max(axis) > left[j].GetRect().Max(axis) })
+		left, right = left[k:], append(right, left[:k]...)
+	}
+	return left, right
+}
+
+func (HilbertSplit) Split(entries []*tree.Entry, minEntries int) ([]*tree.Entry, []*tree.Entry) {
+	sorted := sortByLHV(entries)
+	half := len(sorted) / 2
+	return sorted[:half], sorted[half:]
+}
@@ -0,0 +1,104 @@
+package index
+
+import (
+	"math"
+
+	"github.com/lintang-b-s/rtreed/lib/tree"
+)
+
+// maxTileZoom. 2^z harus muat di int & presisi float64 masih cukup.
+const maxTileZoom = 30
+
+// QueryTile returns the objects inside the Web Mercator (slippy map) tile z/x/y. when the tile holds more than limit
+// objects it returns a spatially even subset instead: the tile is split into a grid of at most limit square
+// sub-cells and at most one object per sub-cell is kept, so dense areas are thinned and sparse ones keep every
+// point. subtrees falling in a single sub-cell that already has its object are skipped. limit <= 0 disables thinning.
+// it needs a lat/lon tree, ErrNotGeographic otherwise.
+func (rt *Rtreed) QueryTile(z, x, y, limit int, opts ...SearchOption) ([]Result, error) {
+	if rt.dim != 2 {
+		return nil, ErrNotGeographic
+	}
+	if z < 0 || z > maxTileZoom || x < 0 || y < 0 || x >= 1<<z || y >= 1<<z {
+		return nil, ErrInvalidTile
+	}
+	cfg := newSearchConfig(opts)
+	rect := tileBounds(z, x, y)
+
+	if limit > 0 {
+		count, err := rt.Count(rect)
+		if err != nil {
+			return nil, err
+		}
+		if count > limit {
+			return rt.thinTile(rect, z, x, y, limit, cfg)
+		}
+	}
+
+	results, err := rt.searchStack(overlapFilter(rect), cfg, make([]Result, 0, 100), PredicateIntersects.accept(rect))
+	if err != nil {
+		rt.Release(results)
+		return nil, err
+	}
+	return results, nil
+}
+
+// thinTile. ambil maksimal satu object per sub-cell dari grid g x g (g*g <= limit) di dalam tile.
+func (rt *Rtreed) thinTile(rect tree.Rect, z, x, y, limit int, cfg searchConfig) ([]Result, error) {
+	g := max(1, int(math.Sqrt(float64(limit))))
+	taken := make([]bool, g*g)
+	cellOf := func(p tree.Point) int {
+		tx, ty := tileFraction(p, z)
+		cx := max(0, min(int((tx-float64(x))*float64(g)), g-1))
+		cy := max(0, min(int((ty-float64(y))*float64(g)), g-1))
+		return cy*g + cx
+	}
+
+	filter := entryFilter{
+		node: func(r tree.Rect) bool {
+			if !rect.Overlaps(r) {
+				return false
+			}
+			// subtree di dalam satu sub-cell yang sudah terisi tidak perlu dibaca
+			if rect.ContainRect(r) {
+				c := cellOf(tree.NewPoint(r.GetSLat(), r.GetSLon()))
+				if c == cellOf(tree.NewPoint(r.GetTLat(), r.GetTLon())) && taken[c] {
+					return false
+				}
+			}
+			return true
+		},
+		leaf: rect.Overlaps,
+	}
+
+	results, err := rt.searchStack(filter, cfg, make([]Result, 0, g*g), func(obj tree.SpatialData) (Result, bool) {
+		c := cellOf(obj.Location())
+		if taken[c] || !rect.Overlaps(obj.Extent()) {
+			return Result{}, false
+		}
+		taken[c] = true
+		return Result{SpatialData: obj}, true
+	})
+	if err != nil {
+		rt.Release(results)
+		return nil, err
+	}
+	return results, nil
+}
+
+// tileBounds returns the lat/lon rectangle of the Web Mercator tile z/x/y.
+func tileBounds(z, x, y int) tree.Rect {
+	n := float64(int(1) << z)
+	lon := func(x int) float64 { return float64(x)/n*360 - 180 }
+	lat := func(y int) float64 { return radiansToDegree(math.Atan(math.Sinh(math.Pi * (1 - 2*float64(y)/n)))) }
+	// tile y bertambah ke arah selatan
+	return tree.NewRectFromBounds(lat(y+1), lon(x), lat(y), lon(x+1))
+}
+
+// tileFraction. posisi p dalam koordinat tile (pecahan) di zoom z, kebalikan dari tileBounds.
+func tileFraction(p tree.Point, z int) (float64, float64) {
+	n := float64(int(1) << z)
+	lat := degreeToRadians(p.Lat)
+	tx := (p.Lon + 180) / 360 * n
+	ty := (1 - math.Log(math.Tan(lat)+1/math.Cos(lat))/math.Pi) / 2 * n
+	return tx, ty
+}
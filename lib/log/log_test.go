@@ -51,7 +51,7 @@ func TestLogManager(t *testing.T) {
 	dm := disk.NewDiskManager("lintangdb", 8192)
 	_, err := os.Stat("lintangdb")
 	if err == nil {
-		os.Remove("lintangdb.log")
+		os.Remove("lintangdb/lintangdb.log")
 	}
 	lm, err := NewLogManager(dm, "lintangdb.log")
 	if err != nil {
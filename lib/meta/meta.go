@@ -6,6 +6,11 @@ import (
 
 const (
 	metaPageNum = 0
+
+	// Magic. penanda page file rtreed ("RTRD"), disimpan di awal meta page.
+	Magic uint32 = 0x44525452
+	// FormatVersion. versi format page. naikkan kalau layout meta/node page berubah.
+	FormatVersion uint16 = 7
 )
 
 type Meta struct {
@@ -14,6 +19,82 @@ type Meta struct {
 	Size         int32
 	freelistPage types.BlockNum
 	nextBlockId  int
+	nextID       uint64 // id object berikutnya, 0 berarti object tanpa id
+
+	magic   uint32
+	version uint16
+
+	// tree configuration, divalidasi ulang tiap kali tree dibuka.
+	dim                   int
+	minEntries            int
+	maxEntries            int
+	maxSpatialDataInBytes int
+	pageSize              int
+	temporal              bool
+}
+
+func (m *Meta) GetMagic() uint32 {
+	return m.magic
+}
+
+func (m *Meta) SetMagic(magic uint32) {
+	m.magic = magic
+}
+
+func (m *Meta) GetVersion() uint16 {
+	return m.version
+}
+
+func (m *Meta) SetVersion(v uint16) {
+	m.version = v
+}
+
+func (m *Meta) GetDim() int {
+	return m.dim
+}
+
+func (m *Meta) SetDim(dim int) {
+	m.dim = dim
+}
+
+func (m *Meta) GetMinEntries() int {
+	return m.minEntries
+}
+
+func (m *Meta) SetMinEntries(min int) {
+	m.minEntries = min
+}
+
+func (m *Meta) GetMaxEntries() int {
+	return m.maxEntries
+}
+
+func (m *Meta) SetMaxEntries(max int) {
+	m.maxEntries = max
+}
+
+func (m *Meta) GetMaxSpatialDataInBytes() int {
+	return m.maxSpatialDataInBytes
+}
+
+func (m *Meta) SetMaxSpatialDataInBytes(size int) {
+	m.maxSpatialDataInBytes = size
+}
+
+func (m *Meta) GetPageSize() int {
+	return m.pageSize
+}
+
+func (m *Meta) SetPageSize(size int) {
+	m.pageSize = size
+}
+
+func (m *Meta) IsTemporal() bool {
+	return m.temporal
+}
+
+func (m *Meta) SetTemporal(temporal bool) {
+	m.temporal = temporal
 }
 
 func (m *Meta) GetFreelistPage() types.BlockNum {
@@ -56,6 +137,14 @@ func (m *Meta) SetNextBlockId(id int) {
 	m.nextBlockId = id
 }
 
+func (m *Meta) GetNextID() uint64 {
+	return m.nextID
+}
+
+func (m *Meta) SetNextID(id uint64) {
+	m.nextID = id
+}
+
 func NewEmptyMeta() *Meta {
-	return &Meta{}
+	return &Meta{magic: Magic, version: FormatVersion, nextID: 1}
 }
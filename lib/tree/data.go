@@ -1,6 +1,9 @@
 package tree
 
 import (
+	"math"
+	"time"
+
 	"github.com/lintang-b-s/rtreed/lib"
 	"github.com/lintang-b-s/rtreed/types"
 )
@@ -65,6 +68,15 @@ func (n *Node) ForEntries(handle func(entry *Entry)) {
 	}
 }
 
+// Count returns the number of objects in the node's subtree.
+func (n *Node) Count() int {
+	count := 0
+	for _, e := range n.entries {
+		count += e.count
+	}
+	return count
+}
+
 func (n *Node) GetEntriesSize() int {
 	return len(n.entries)
 }
@@ -89,15 +101,19 @@ type Entry struct {
 	obj   SpatialData    // var(max_obj) size
 	rect  Rect           // 32 bytes
 	child types.BlockNum // 8 bytes
+	count int            // 8 bytes. jumlah object di subtree, 1 untuk leaf entry
+	lhv   HilbertKey     // 16 bytes. hilbert key terbesar di subtree, hilbert key rect untuk leaf entry
 }
 
-// var(max_obj) + 40 bytes
+// var(max_obj) + 64 bytes
 
 func NewEntry(r Rect, c types.BlockNum, o SpatialData) *Entry {
 	return &Entry{
 		rect:  r,
 		child: c,
 		obj:   o,
+		count: 1,
+		lhv:   HilbertKeyOf(r),
 	}
 }
 
@@ -113,6 +129,25 @@ func (n *Entry) GetRect() Rect {
 	return n.rect
 }
 
+// GetCount returns the number of objects in the entry's subtree, 1 for a leaf entry.
+func (n *Entry) GetCount() int {
+	return n.count
+}
+
+func (n *Entry) SetCount(c int) {
+	n.count = c
+}
+
+// GetLHV returns the largest Hilbert value (LHV) in the entry's subtree, the Hilbert key of its rectangle for a leaf
+// entry.
+func (n *Entry) GetLHV() HilbertKey {
+	return n.lhv
+}
+
+func (n *Entry) SetLHV(lhv HilbertKey) {
+	n.lhv = lhv
+}
+
 func (n *Entry) SetChild(c types.BlockNum) {
 	n.child = c
 }
@@ -125,13 +160,67 @@ func (n *Entry) SetRect(r Rect) {
 	n.rect = r
 }
 
+// GeometryType tags what a SpatialData's extent describes.
+type GeometryType uint8
+
+const (
+	GeometryPoint GeometryType = iota
+	GeometryRect
+	GeometryLine    // extent is the MBR of the line
+	GeometryPolygon // extent is the MBR of the polygon
+)
+
 type SpatialData struct {
 	location Point
 	data     []byte
+	geomType GeometryType
+	extent   Rect
+
+	// interval waktu object (lihat TimeCoord), cuma ada di tree Temporal.
+	timed      bool
+	start, end float64
+
+	// id dari tree, 0 untuk object yang belum diinsert.
+	id uint64
 }
 
 func NewSpatialData(p Point, d []byte) SpatialData {
-	return SpatialData{location: p, data: d}
+	return SpatialData{location: p, data: d, geomType: GeometryPoint, extent: p.ToRect(0)}
+}
+
+// NewSpatialDataWithExtent creates an object covering extent. its Location is the center of extent.
+func NewSpatialDataWithExtent(geomType GeometryType, extent Rect, d []byte) SpatialData {
+	center := extent.s
+	if geomType != GeometryPoint {
+		for i := 0; i < extent.Dims(); i++ {
+			center.setCoord(i, (extent.s.Coord(i)+extent.t.Coord(i))/2)
+		}
+	}
+	return SpatialData{location: center, data: d, geomType: geomType, extent: extent}
+}
+
+// NewSpatialDataRect creates a rectangle object.
+func NewSpatialDataRect(r Rect, d []byte) SpatialData {
+	return NewSpatialDataWithExtent(GeometryRect, r, d)
+}
+
+// NewSpatialDataLine creates a line object indexed by the MBR of its points. a line without points has an empty
+// extent (see Rect.IsEmpty), which the tree refuses to insert.
+func NewSpatialDataLine(line []Point, d []byte) SpatialData {
+	if len(line) == 0 {
+		return NewSpatialDataWithExtent(GeometryLine, NewRectFromBounds(math.Inf(1), math.Inf(1), math.Inf(-1),
+			math.Inf(-1)), d)
+	}
+	extent := line[0].ToRect(0)
+	for _, p := range line[1:] {
+		extent = CreateRectangle(extent, p.ToRect(0))
+	}
+	return NewSpatialDataWithExtent(GeometryLine, extent, d)
+}
+
+// NewSpatialDataPolygon creates a polygon object indexed by the MBR of its exterior ring.
+func NewSpatialDataPolygon(pg Polygon, d []byte) SpatialData {
+	return NewSpatialDataWithExtent(GeometryPolygon, pg.Bounds(), d)
 }
 
 func (sd *SpatialData) Data() []byte {
@@ -142,15 +231,94 @@ func (sd *SpatialData) SetData(d []byte) {
 	sd.data = d
 }
 
+// Location. titik object, untuk object dengan extent ini titik tengah extent nya.
 func (sd *SpatialData) Location() Point {
 	return sd.location
 }
 func (sd *SpatialData) SetLocation(p Point) {
 	sd.location = p
+	sd.geomType = GeometryPoint
+	sd.extent = p.ToRect(0)
+}
+
+func (sd *SpatialData) GeometryType() GeometryType {
+	return sd.geomType
+}
+
+// ID returns the id the tree assigned to sd on insert, 0 for an object that was not read from a tree.
+func (sd *SpatialData) ID() uint64 {
+	return sd.id
+}
+
+// WithID returns a copy of sd carrying id. ids are handed out by the tree, this is for the storage layer.
+func (sd SpatialData) WithID(id uint64) SpatialData {
+	sd.id = id
+	return sd
+}
+
+// MoveTo returns a copy of sd whose Location is p. an extent is translated along, keeping its shape.
+func (sd SpatialData) MoveTo(p Point) SpatialData {
+	for i := 0; i < sd.extent.Dims(); i++ {
+		d := p.Coord(i) - sd.location.Coord(i)
+		sd.extent.s.setCoord(i, sd.extent.s.Coord(i)+d)
+		sd.extent.t.setCoord(i, sd.extent.t.Coord(i)+d)
+	}
+	sd.location = p
+	return sd
+}
+
+// TimeCoord returns the time axis coordinate of t: seconds since the Unix epoch, with microsecond precision.
+func TimeCoord(t time.Time) float64 {
+	return float64(t.UnixMicro()) / 1e6
+}
+
+// WithTimeRange returns a copy of sd valid from start to end, the form objects take in a Temporal tree.
+// a ping at one instant has start == end.
+func (sd SpatialData) WithTimeRange(start, end time.Time) SpatialData {
+	return sd.WithTimeCoords(TimeCoord(start), TimeCoord(end))
+}
+
+// WithTimeCoords is WithTimeRange with the interval as TimeCoord values.
+func (sd SpatialData) WithTimeCoords(start, end float64) SpatialData {
+	sd.timed, sd.start, sd.end = true, start, end
+	return sd
+}
+
+// TimeRange returns the interval set by WithTimeRange, ok is false for objects without one.
+func (sd *SpatialData) TimeRange() (start, end time.Time, ok bool) {
+	if !sd.timed {
+		return time.Time{}, time.Time{}, false
+	}
+	return coordTime(sd.start), coordTime(sd.end), true
+}
+
+// TimeCoords is TimeRange as TimeCoord values.
+func (sd *SpatialData) TimeCoords() (start, end float64, ok bool) {
+	return sd.start, sd.end, sd.timed
+}
+
+// coordTime. kebalikan TimeCoord.
+func coordTime(c float64) time.Time {
+	return time.UnixMicro(int64(math.Round(c * 1e6)))
 }
 
 var tol = 0.0001
 
+// Bounds. rect yang disimpan di leaf entry, extent diperbesar sebesar tol, ditambah axis waktu kalau object punya
+// interval waktu.
 func (s *SpatialData) Bounds() Rect {
-	return s.Location().ToRect(tol)
+	r := s.extent
+	for i := 0; i < r.Dims(); i++ {
+		r.s.setCoord(i, r.s.Coord(i)-tol)
+		r.t.setCoord(i, r.t.Coord(i)+tol)
+	}
+	if s.timed {
+		r = r.AppendAxis(s.start, s.end)
+	}
+	return r
+}
+
+// Extent. true extent dari object (tanpa tol), dipakai buat cek predicate query.
+func (s *SpatialData) Extent() Rect {
+	return s.extent
 }
@@ -0,0 +1,85 @@
+package tree
+
+import (
+	"math"
+)
+
+// hilbertAxisBits. bit per axis di hilbert key, MaxDims axis muat di 128 bit.
+const hilbertAxisBits = 128 / MaxDims
+
+// HilbertKey is the position of a point along the Hilbert curve, a 128 bit unsigned value (Hi then Lo).
+type HilbertKey struct {
+	Hi, Lo uint64
+}
+
+// Less reports whether k comes before other on the curve.
+func (k HilbertKey) Less(other HilbertKey) bool {
+	if k.Hi != other.Hi {
+		return k.Hi < other.Hi
+	}
+	return k.Lo < other.Lo
+}
+
+// HilbertKeyOf returns the Hilbert key of r's center. every coordinate is mapped to an integer ordered like the
+// float itself, so the curve covers all float64 values and needs no bounding box of the data. its grid is finer
+// near 0, but close points still get close keys.
+func HilbertKeyOf(r Rect) HilbertKey {
+	var axes [MaxDims]uint32
+	for i := 0; i < r.Dims(); i++ {
+		axes[i] = uint32(orderedFloatBits((r.Min(i)+r.Max(i))/2) >> (64 - hilbertAxisBits))
+	}
+	return hilbertIndex(axes[:r.Dims()], hilbertAxisBits)
+}
+
+// orderedFloatBits. bit x sebagai uint64 yang urutannya sama dengan urutan float nya.
+func orderedFloatBits(x float64) uint64 {
+	b := math.Float64bits(x)
+	if b>>63 == 1 {
+		return ^b
+	}
+	return b | 1<<63
+}
+
+// hilbertIndex. index Hilbert titik x (bits bit per axis), Skilling 2004 "Programming the Hilbert curve": x diubah
+// ke transpose index nya, lalu bit nya diinterleave dari bit paling signifikan. x ikut diubah.
+func hilbertIndex(x []uint32, bits int) HilbertKey {
+	n := len(x)
+	m := uint32(1) << (bits - 1)
+
+	// inverse undo
+	for q := m; q > 1; q >>= 1 {
+		p := q - 1
+		for i := 0; i < n; i++ {
+			if x[i]&q != 0 {
+				x[0] ^= p
+			} else {
+				t := (x[0] ^ x[i]) & p
+				x[0] ^= t
+				x[i] ^= t
+			}
+		}
+	}
+
+	// gray encode
+	for i := 1; i < n; i++ {
+		x[i] ^= x[i-1]
+	}
+	t := uint32(0)
+	for q := m; q > 1; q >>= 1 {
+		if x[n-1]&q != 0 {
+			t ^= q - 1
+		}
+	}
+	for i := 0; i < n; i++ {
+		x[i] ^= t
+	}
+
+	var key HilbertKey
+	for b := bits - 1; b >= 0; b-- {
+		for i := 0; i < n; i++ {
+			key.Hi = key.Hi<<1 | key.Lo>>63
+			key.Lo = key.Lo<<1 | uint64(x[i]>>b&1)
+		}
+	}
+	return key
+}
@@ -0,0 +1,142 @@
+package tree
+
+import "math"
+
+// Polygon is a simple polygon with optional holes. coordinates are treated as planar lat/lon, edges are straight
+// lines in degrees, not great-circle arcs. a ring may be given open or closed (last point equal to the first).
+type Polygon struct {
+	exterior []Point
+	holes    [][]Point
+	bounds   Rect
+}
+
+func NewPolygon(exterior []Point, holes ...[]Point) Polygon {
+	pg := Polygon{exterior: openRing(exterior)}
+	for _, h := range holes {
+		pg.holes = append(pg.holes, openRing(h))
+	}
+
+	pg.bounds = NewRectFromBounds(math.Inf(1), math.Inf(1), math.Inf(-1), math.Inf(-1))
+	for _, p := range pg.exterior {
+		pg.bounds.s.Lat = math.Min(pg.bounds.s.Lat, p.Lat)
+		pg.bounds.s.Lon = math.Min(pg.bounds.s.Lon, p.Lon)
+		pg.bounds.t.Lat = math.Max(pg.bounds.t.Lat, p.Lat)
+		pg.bounds.t.Lon = math.Max(pg.bounds.t.Lon, p.Lon)
+	}
+	return pg
+}
+
+// openRing. buang titik terakhir kalau sama dengan titik pertama.
+func openRing(ring []Point) []Point {
+	if len(ring) > 1 && ring[0] == ring[len(ring)-1] {
+		ring = ring[:len(ring)-1]
+	}
+	return ring
+}
+
+// Bounds returns the MBR of the exterior ring.
+func (pg Polygon) Bounds() Rect {
+	return pg.bounds
+}
+
+// ContainsPoint reports whether p is inside the polygon. points on the exterior ring or on a hole's ring count
+// as inside, points strictly inside a hole don't.
+func (pg Polygon) ContainsPoint(p Point) bool {
+	if !pg.bounds.ContainRect(p.ToRect(0)) {
+		return false
+	}
+	inside, onEdge := ringContains(pg.exterior, p)
+	if onEdge {
+		return true
+	}
+	if !inside {
+		return false
+	}
+	for _, h := range pg.holes {
+		inHole, onHoleEdge := ringContains(h, p)
+		if inHole && !onHoleEdge {
+			return false
+		}
+	}
+	return true
+}
+
+// IntersectsRect reports whether r shares at least one point with the polygon.
+func (pg Polygon) IntersectsRect(r Rect) bool {
+	if !pg.bounds.Overlaps(r) {
+		return false
+	}
+
+	corners := [4]Point{r.s, NewPoint(r.s.Lat, r.t.Lon), r.t, NewPoint(r.t.Lat, r.s.Lon)}
+	for _, c := range corners {
+		if pg.ContainsPoint(c) {
+			return true
+		}
+	}
+
+	// tidak ada corner r di dalam polygon: r cuma bisa intersect kalau ada vertex polygon di dalam r
+	// atau ada edge polygon yang memotong edge r. kalau tidak, r di luar polygon atau di dalam hole.
+	for _, ring := range pg.rings() {
+		for i := range ring {
+			a, b := ring[i], ring[(i+1)%len(ring)]
+			if r.ContainRect(a.ToRect(0)) {
+				return true
+			}
+			for j := range corners {
+				if segmentsIntersect(a, b, corners[j], corners[(j+1)%4]) {
+					return true
+				}
+			}
+		}
+	}
+	return false
+}
+
+func (pg Polygon) rings() [][]Point {
+	return append([][]Point{pg.exterior}, pg.holes...)
+}
+
+// ringContains. ray casting ke arah lon positif, onEdge true kalau p tepat di salah satu edge ring.
+func ringContains(ring []Point, p Point) (inside, onEdge bool) {
+	for i := range ring {
+		a, b := ring[i], ring[(i+1)%len(ring)]
+		if orientation(a, b, p) == 0 && onSegment(a, b, p) {
+			return true, true
+		}
+		if (a.Lat > p.Lat) != (b.Lat > p.Lat) {
+			lon := a.Lon + (p.Lat-a.Lat)*(b.Lon-a.Lon)/(b.Lat-a.Lat)
+			if p.Lon < lon {
+				inside = !inside
+			}
+		}
+	}
+	return inside, false
+}
+
+// orientation. tanda cross product (b-a) x (c-a): 1 counter clockwise, -1 clockwise, 0 collinear.
+func orientation(a, b, c Point) int {
+	cross := (b.Lon-a.Lon)*(c.Lat-a.Lat) - (b.Lat-a.Lat)*(c.Lon-a.Lon)
+	switch {
+	case cross > 0:
+		return 1
+	case cross < 0:
+		return -1
+	}
+	return 0
+}
+
+// onSegment. p collinear dengan a-b, cek p di dalam bounding box segment.
+func onSegment(a, b, p Point) bool {
+	return p.Lat >= math.Min(a.Lat, b.Lat) && p.Lat <= math.Max(a.Lat, b.Lat) &&
+		p.Lon >= math.Min(a.Lon, b.Lon) && p.Lon <= math.Max(a.Lon, b.Lon)
+}
+
+func segmentsIntersect(a, b, c, d Point) bool {
+	o1, o2 := orientation(a, b, c), orientation(a, b, d)
+	o3, o4 := orientation(c, d, a), orientation(c, d, b)
+	if o1 != o2 && o3 != o4 {
+		return true
+	}
+	return (o1 == 0 && onSegment(a, b, c)) || (o2 == 0 && onSegment(a, b, d)) ||
+		(o3 == 0 && onSegment(c, d, a)) || (o4 == 0 && onSegment(c, d, b))
+}
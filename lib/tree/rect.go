@@ -1,8 +1,17 @@
 package tree
 
+import "errors"
+
+// MaxDims is the number of axes a Point or Rect can hold. axis 0 is Lat (x) and axis 1 is Lon (y), the
+// 2 dimensional lat/lon point is the zero value layout, extra axes (altitude, time, ...) follow them.
+const MaxDims = 4
+
 type Point struct {
 	Lat float64
 	Lon float64
+
+	more [MaxDims - 2]float64 // axis 2 & 3
+	dims uint8                // 0 untuk point 2 dimensi, biar NewPoint(lat, lon) == NewPointN(lat, lon)
 }
 
 func NewPoint(lat, lon float64) Point {
@@ -12,10 +21,59 @@ func NewPoint(lat, lon float64) Point {
 	}
 }
 
+// NewPointN creates a point from 2 up to MaxDims coordinates, NewPointN(x, y) equals NewPoint(x, y).
+func NewPointN(coords ...float64) Point {
+	var p Point
+	for i, c := range coords {
+		p.setCoord(i, c)
+	}
+	if len(coords) > 2 {
+		p.dims = uint8(len(coords))
+	}
+	return p
+}
+
+// Dims returns the number of axes of p.
+func (p Point) Dims() int {
+	if p.dims == 0 {
+		return 2
+	}
+	return int(p.dims)
+}
+
+// Coord returns p's coordinate on axis i.
+func (p Point) Coord(i int) float64 {
+	switch i {
+	case 0:
+		return p.Lat
+	case 1:
+		return p.Lon
+	}
+	return p.more[i-2]
+}
+
+func (p *Point) setCoord(i int, v float64) {
+	switch i {
+	case 0:
+		p.Lat = v
+	case 1:
+		p.Lon = v
+	default:
+		p.more[i-2] = v
+	}
+}
+
+// NewRect creates the rect from p with the given side lengths, one per axis of p.
 func NewRect(p Point, lengths []float64) (r Rect, err error) {
+	if len(lengths) != p.Dims() {
+		return r, errors.New("lengths must have one side length per axis of the point")
+	}
 
-	r.t.Lat = p.Lat + lengths[0]
-	r.t.Lon = p.Lon + lengths[1]
+	r.s = p
+	r.t = p
+	for i, l := range lengths {
+		r.t.setCoord(i, p.Coord(i)+l)
+	}
 	return
 }
 
@@ -28,20 +86,22 @@ func NewRectFromBounds(sLat, sLon, tLat, tLon float64) (r Rect) {
 	return
 }
 
+// NewRectFromPoints creates the rect with lower corner s and upper corner t.
+func NewRectFromPoints(s, t Point) Rect {
+	return Rect{s: s, t: t}
+}
+
+// MinDist. squared euclidean distance dari p ke titik terdekat r, axis yang tidak dimiliki salah satunya diabaikan.
 func (p Point) MinDist(r Rect) float64 {
 
 	sum := 0.0
-
-	if p.Lat < r.s.Lat {
-		sum += (p.Lat - r.s.Lat) * (p.Lat - r.s.Lat)
-	} else if p.Lat > r.t.Lat {
-		sum += (p.Lat - r.t.Lat) * (p.Lat - r.t.Lat)
-	}
-
-	if p.Lon < r.s.Lon {
-		sum += (p.Lon - r.s.Lon) * (p.Lon - r.s.Lon)
-	} else if p.Lon > r.t.Lon {
-		sum += (p.Lon - r.t.Lon) * (p.Lon - r.t.Lon)
+	for i := 0; i < min(p.Dims(), r.Dims()); i++ {
+		c := p.Coord(i)
+		if s := r.s.Coord(i); c < s {
+			sum += (c - s) * (c - s)
+		} else if t := r.t.Coord(i); c > t {
+			sum += (c - t) * (c - t)
+		}
 	}
 
 	return sum
@@ -51,6 +111,62 @@ type Rect struct {
 	s, t Point
 }
 
+// Dims returns the number of axes of r.
+func (r Rect) Dims() int {
+	return max(r.s.Dims(), r.t.Dims())
+}
+
+// IsEmpty reports whether r covers no point, like the extent of a line or polygon without points.
+func (r Rect) IsEmpty() bool {
+	for i := 0; i < r.Dims(); i++ {
+		if !(r.s.Coord(i) <= r.t.Coord(i)) {
+			return true
+		}
+	}
+	return false
+}
+
+// Min returns the lower bound of r on axis i.
+func (r Rect) Min(i int) float64 {
+	return r.s.Coord(i)
+}
+
+// Max returns the upper bound of r on axis i.
+func (r Rect) Max(i int) float64 {
+	return r.t.Coord(i)
+}
+
+// Lower & Upper return the corners of r.
+func (r Rect) Lower() Point {
+	return r.s
+}
+
+func (r Rect) Upper() Point {
+	return r.t
+}
+
+// AppendAxis returns r with one more axis spanning lo..hi after its last one.
+func (r Rect) AppendAxis(lo, hi float64) Rect {
+	dims := r.Dims()
+	r.s.setCoord(dims, lo)
+	r.t.setCoord(dims, hi)
+	r.s.dims, r.t.dims = uint8(dims+1), uint8(dims+1)
+	return r
+}
+
+// Project returns r restricted to its first dims axes.
+func (r Rect) Project(dims int) Rect {
+	p := Rect{}
+	for i := 0; i < dims; i++ {
+		p.s.setCoord(i, r.s.Coord(i))
+		p.t.setCoord(i, r.t.Coord(i))
+	}
+	if dims > 2 {
+		p.s.dims, p.t.dims = uint8(dims), uint8(dims)
+	}
+	return p
+}
+
 func (r Rect) GetSLat() float64 {
 	return r.s.Lat
 }
@@ -84,26 +200,20 @@ func (r *Rect) SetTLon(tLon float64) {
 }
 
 func (r Rect) Equal(other Rect) bool {
-
-	if r.s.Lat != other.s.Lat || r.s.Lon != other.s.Lon {
-		return false
-	}
-
-	if r.t.Lat != other.t.Lat || r.t.Lon != other.t.Lon {
-		return false
-	}
-
-	return true
+	return r.s == other.s && r.t == other.t
 }
 
 func (r Rect) Area() float64 {
 	size := 1.0
 
-	size *= r.t.Lat - r.s.Lat
-	size *= r.t.Lon - r.s.Lon
+	for i := 0; i < r.Dims(); i++ {
+		size *= r.t.Coord(i) - r.s.Coord(i)
+	}
 	return size
 }
 
+// ContainRect & Overlaps compare the axes both rects have, so a 2 dimensional query rect matches
+// N dimensional entries on their first two axes only.
 func (r Rect) ContainRect(r2 Rect) bool {
 
 	if r.s.Lat > r2.s.Lat || r2.t.Lat > r.t.Lat {
@@ -114,6 +224,12 @@ func (r Rect) ContainRect(r2 Rect) bool {
 		return false
 	}
 
+	for i := 2; i < min(r.Dims(), r2.Dims()); i++ {
+		if r.s.more[i-2] > r2.s.more[i-2] || r2.t.more[i-2] > r.t.more[i-2] {
+			return false
+		}
+	}
+
 	return true
 }
 
@@ -126,16 +242,22 @@ func (r Rect) Overlaps(r2 Rect) bool {
 	if r.s.Lon > r2.t.Lon || r2.s.Lon > r.t.Lon {
 		return false
 	}
+
+	for i := 2; i < min(r.Dims(), r2.Dims()); i++ {
+		if r.s.more[i-2] > r2.t.more[i-2] || r2.s.more[i-2] > r.t.more[i-2] {
+			return false
+		}
+	}
 	return true
 }
 
 func (p Point) ToRect(tol float64) Rect {
 
-	r := Rect{}
-	r.s.Lat = p.Lat - tol
-	r.s.Lon = p.Lon - tol
-	r.t.Lat = p.Lat + tol
-	r.t.Lon = p.Lon + tol
+	r := Rect{s: p, t: p}
+	for i := 0; i < p.Dims(); i++ {
+		r.s.setCoord(i, p.Coord(i)-tol)
+		r.t.setCoord(i, p.Coord(i)+tol)
+	}
 
 	return r
 }
@@ -167,5 +289,14 @@ func CreateRectangle(r1, r2 Rect) (bb Rect) {
 		bb.t.Lon = r1.t.Lon
 	}
 
+	dims := max(r1.Dims(), r2.Dims())
+	for i := 2; i < dims; i++ {
+		bb.s.more[i-2] = min(r1.s.more[i-2], r2.s.more[i-2])
+		bb.t.more[i-2] = max(r1.t.more[i-2], r2.t.more[i-2])
+	}
+	if dims > 2 {
+		bb.s.dims, bb.t.dims = uint8(dims), uint8(dims)
+	}
+
 	return
 }
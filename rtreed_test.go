@@ -1,11 +1,21 @@
 package main
 
 import (
+	"bytes"
+	"errors"
 	"fmt"
+	"iter"
+	"math"
+	"os"
+	"path/filepath"
+	"slices"
+	"sort"
+	"strings"
 	"testing"
 	"time"
 
 	"github.com/brianvoe/gofakeit/v7"
+	"github.com/lintang-b-s/rtreed/lib/disk"
 	"github.com/lintang-b-s/rtreed/lib/index"
 	"github.com/lintang-b-s/rtreed/lib/tree"
 )
@@ -25,7 +35,9 @@ func TestInsert(t *testing.T) {
 		randomLat, _ := faker.LatitudeInRange(-7.818711242232534, -7.767187043571421)
 		randomLon, _ := faker.LongitudeInRange(110.32382482774563, 110.42872530361015)
 		point := tree.NewPoint(randomLat, randomLon)
-		rtd.Insert(tree.NewSpatialData(point, []byte("coba")))
+		if err := rtd.Insert(tree.NewSpatialData(point, []byte("coba"))); err != nil {
+			t.Fatal(err)
+		}
 	}
 
 	fmt.Printf("Total time to insert: %v seconds\n", time.Since(startTimer).Seconds())
@@ -41,12 +53,18 @@ func TestInsert(t *testing.T) {
 	query := tree.NewPoint(-7.767559872795658, 110.37630049924584)
 
 	for i := 0; i < 100; i++ {
-		results := rtdRead.SearchWithinRadius(query, 0.035)
-		for _, res := range results {
+		results, err := rtdRead.SearchWithinRadius(query, 0.035, index.WithExactDistance(), index.WithSortByDistance())
+		if err != nil {
+			t.Fatal(err)
+		}
+		for j, res := range results {
 			dist := index.HaversineDistance(query.Lat, query.Lon, res.Location().Lat, res.Location().Lon)
-			if dist > 0.035*2.0 {
+			if dist > 0.035 {
 				t.Errorf("Data found outside radius: %v > %v", dist, 0.035)
 			}
+			if j > 0 && results[j-1].Distance > res.Distance {
+				t.Errorf("results not sorted by distance: %v > %v", results[j-1].Distance, res.Distance)
+			}
 		}
 	}
 
@@ -68,7 +86,10 @@ func BenchmarkSearch(b *testing.B) {
 		randomLat, _ := faker.LatitudeInRange(-7.818711242232534, -7.767187043571421)
 		randomLon, _ := faker.LongitudeInRange(110.32382482774563, 110.42872530361015)
 		point := tree.NewPoint(randomLat, randomLon)
-		results := rtd.SearchWithinRadius(point, 0.035) // 35 meter radius
+		results, err := rtd.SearchWithinRadius(point, 0.035) // 35 meter radius
+		if err != nil {
+			b.Fatal(err)
+		}
 		if len(results) != 0 {
 			_ = results
 		}
@@ -78,3 +99,2345 @@ func BenchmarkSearch(b *testing.B) {
 	throughput := float64(b.N) / b.Elapsed().Seconds()
 	b.ReportMetric(throughput, "ops/sec")
 }
+
+func TestOpenSideBySide(t *testing.T) {
+	jogja, err := index.Open(t.TempDir(), index.Options{Dim: 2, MinEntries: 4, MaxEntries: 8, MaxSpatialDataInBytes: 16,
+		BufferPoolSizeInMB: 1})
+	if err != nil {
+		t.Fatal(err)
+	}
+	solo, err := index.Open(t.TempDir(), index.Options{Dim: 2, MinEntries: 25, MaxEntries: 50, MaxSpatialDataInBytes: 4,
+		PageSize: 8192, PageFileName: "solo.page", LogFileName: "solo.log"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	faker := gofakeit.New(0)
+	for i := 0; i < 1000; i++ {
+		lat, _ := faker.LatitudeInRange(-7.818711242232534, -7.767187043571421)
+		lon, _ := faker.LongitudeInRange(110.32382482774563, 110.42872530361015)
+		if err := jogja.Insert(tree.NewSpatialData(tree.NewPoint(lat, lon), []byte("jogja"))); err != nil {
+			t.Fatal(err)
+		}
+
+		lat, _ = faker.LatitudeInRange(-7.60, -7.53)
+		lon, _ = faker.LongitudeInRange(110.78, 110.86)
+		if err := solo.Insert(tree.NewSpatialData(tree.NewPoint(lat, lon), []byte("solo"))); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	jogjaResults, err := jogja.SearchWithinRadius(tree.NewPoint(-7.79, 110.37), 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	soloResults, err := solo.SearchWithinRadius(tree.NewPoint(-7.79, 110.37), 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(jogjaResults) == 0 {
+		t.Errorf("expected results from the jogja tree")
+	}
+	if len(soloResults) != 0 {
+		t.Errorf("expected no results from the solo tree, got %d", len(soloResults))
+	}
+
+	if err := jogja.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if err := solo.Close(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestReopenValidatesStoredConfig(t *testing.T) {
+	dir := t.TempDir()
+	rtd, err := index.Open(dir, index.Options{Dim: 2, MinEntries: 4, MaxEntries: 8, MaxSpatialDataInBytes: 16})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := rtd.Insert(tree.NewSpatialData(tree.NewPoint(-7.79, 110.37), []byte("tugu"))); err != nil {
+		t.Fatal(err)
+	}
+	if err := rtd.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	_, err = index.Open(dir, index.Options{Dim: 2, MinEntries: 4, MaxEntries: 10, MaxSpatialDataInBytes: 16})
+	var mismatch *index.ConfigMismatchError
+	if !errors.As(err, &mismatch) {
+		t.Fatalf("expected ConfigMismatchError, got %v", err)
+	}
+	if mismatch.Field != "max entries" || mismatch.Stored != 8 || mismatch.Given != 10 {
+		t.Errorf("unexpected mismatch: %v", mismatch)
+	}
+
+	// zero options adopt the stored configuration
+	rtd, err = index.Open(dir, index.Options{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	results, err := rtd.SearchWithinRadius(tree.NewPoint(-7.79, 110.37), 0.1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(results) != 1 || string(results[0].Data()) != "tugu" {
+		t.Errorf("expected the stored point after reopen, got %v", results)
+	}
+	if err := rtd.Close(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestPayloadTooLarge(t *testing.T) {
+	rtd, err := index.Open(t.TempDir(), index.Options{Dim: 2, MinEntries: 2, MaxEntries: 4, MaxSpatialDataInBytes: 8})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer rtd.Close()
+
+	p := tree.NewPoint(-7.79, 110.37)
+	id, err := rtd.InsertID(tree.NewSpatialData(p, []byte("tugu")))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, size := range []int{9, 200, 20000} {
+		big := tree.NewSpatialData(p, bytes.Repeat([]byte("x"), size))
+		if err := rtd.Insert(big); !errors.Is(err, index.ErrPayloadTooLarge) {
+			t.Errorf("Insert %d bytes: expected ErrPayloadTooLarge, got %v", size, err)
+		}
+		if err := rtd.Update(tree.NewSpatialData(p, []byte("tugu")), big); !errors.Is(err, index.ErrPayloadTooLarge) {
+			t.Errorf("Update %d bytes: expected ErrPayloadTooLarge, got %v", size, err)
+		}
+		if err := rtd.UpdateByID(id, big); !errors.Is(err, index.ErrPayloadTooLarge) {
+			t.Errorf("UpdateByID %d bytes: expected ErrPayloadTooLarge, got %v", size, err)
+		}
+	}
+
+	for _, name := range []string{"BulkLoad", "BulkLoadHilbert"} {
+		empty, err := index.Open(t.TempDir(), index.Options{Dim: 2, MinEntries: 2, MaxEntries: 4, MaxSpatialDataInBytes: 8})
+		if err != nil {
+			t.Fatal(err)
+		}
+		load := empty.BulkLoad
+		if name == "BulkLoadHilbert" {
+			load = empty.BulkLoadHilbert
+		}
+		objs := []tree.SpatialData{tree.NewSpatialData(p, []byte("ok")), tree.NewSpatialData(p, make([]byte, 200))}
+		if err := load(slices.Values(objs)); !errors.Is(err, index.ErrPayloadTooLarge) {
+			t.Errorf("%s: expected ErrPayloadTooLarge, got %v", name, err)
+		}
+		empty.Close()
+	}
+
+	// the rejected objects left the tree as it was
+	for i := 0; i < 20; i++ {
+		if err := rtd.Insert(tree.NewSpatialData(p, []byte("12345678"))); err != nil {
+			t.Fatal(err)
+		}
+	}
+	obj, err := rtd.Get(id)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(obj.Data()) != "tugu" {
+		t.Errorf("expected the original payload, got %q", obj.Data())
+	}
+	if n, err := rtd.Count(tree.NewRectFromBounds(-8, 110, -7, 111)); err != nil || n != 21 {
+		t.Errorf("expected 21 objects, got %d (%v)", n, err)
+	}
+}
+
+func TestBufferPoolExhausted(t *testing.T) {
+	// 1 MB of 32 KB pages is a 32 frame buffer pool
+	rtd, err := index.Open(t.TempDir(), index.Options{Dim: 2, MinEntries: 4, MaxEntries: 8, MaxSpatialDataInBytes: 16,
+		PageSize: 32768, BufferPoolSizeInMB: 1})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer rtd.Close()
+
+	faker := gofakeit.New(0)
+	objs := make([]tree.SpatialData, 0, 1000)
+	for i := 0; i < 1000; i++ {
+		lat, _ := faker.LatitudeInRange(-8, -7)
+		lon, _ := faker.LongitudeInRange(110, 111)
+		objs = append(objs, tree.NewSpatialData(tree.NewPoint(lat, lon), []byte(fmt.Sprintf("p%d", i))))
+		if err := rtd.Insert(objs[i]); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	isPoolError := func(err error) bool {
+		return errors.Is(err, index.ErrNoAvailableFrame) || errors.Is(err, index.ErrAllPagesPinned)
+	}
+
+	// zero copy results keep their leaf pages pinned until Release. a query failing for lack of frames releases its
+	// own pins, which the next queries pick up, so at the end every frame holds a leaf.
+	held := make([][]index.Result, 0, 1000)
+	failed := 0
+	for i := 0; i < 100; i++ {
+		for j := 0; j < 100; j++ {
+			cell := tree.NewRectFromBounds(-8+float64(i)*0.01, 110+float64(j)*0.01, -8+float64(i+1)*0.01,
+				110+float64(j+1)*0.01)
+			results, err := rtd.Search(cell, index.PredicateIntersects, index.WithZeroCopy())
+			if err != nil {
+				if !isPoolError(err) {
+					t.Fatalf("expected a buffer pool error, got %v", err)
+				}
+				failed++
+				continue
+			}
+			held = append(held, results)
+		}
+	}
+	if failed == 0 {
+		t.Fatal("expected the buffer pool to run out of frames")
+	}
+
+	p := tree.NewPoint(-7.5, 110.5)
+	if err := rtd.Insert(tree.NewSpatialData(p, []byte("extra"))); !isPoolError(err) {
+		t.Errorf("Insert: expected a buffer pool error, got %v", err)
+	}
+	if _, err := rtd.Delete(objs[0]); !isPoolError(err) {
+		t.Errorf("Delete: expected a buffer pool error, got %v", err)
+	}
+	if _, err := rtd.NearestNeighbors(5, p); !isPoolError(err) {
+		t.Errorf("NearestNeighbors: expected a buffer pool error, got %v", err)
+	}
+	if _, err := rtd.SearchWithinRadius(p, 50); !isPoolError(err) {
+		t.Errorf("SearchWithinRadius: expected a buffer pool error, got %v", err)
+	}
+
+	// the failed calls released their pins, so the tree works again once the held pages are released
+	for _, results := range held {
+		rtd.Release(results)
+	}
+	if err := rtd.Insert(tree.NewSpatialData(p, []byte("extra"))); err != nil {
+		t.Fatal(err)
+	}
+	if found, err := rtd.Delete(objs[0]); err != nil || !found {
+		t.Fatalf("Delete: found %v, err %v", found, err)
+	}
+	for i := 0; i < 20; i++ {
+		results, err := rtd.Search(tree.NewRectFromBounds(-8, 110, -7, 111), index.PredicateIntersects)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(results) != 1000 {
+			t.Fatalf("expected 1000 objects, got %d", len(results))
+		}
+	}
+	if nearest, err := rtd.NearestNeighbors(5, p); err != nil || len(nearest) != 5 {
+		t.Errorf("NearestNeighbors: got %d results, err %v", len(nearest), err)
+	}
+}
+
+func TestDeleteUntilEmpty(t *testing.T) {
+	rtd, err := index.Open(t.TempDir(), index.Options{Dim: 2, MinEntries: 2, MaxEntries: 4, MaxSpatialDataInBytes: 8})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer rtd.Close()
+
+	faker := gofakeit.New(0)
+	all := tree.NewRectFromBounds(-8, 110, -7, 111)
+	for round := 0; round < 3; round++ {
+		objs := make([]tree.SpatialData, 0, 500)
+		for i := 0; i < 500; i++ {
+			lat, _ := faker.LatitudeInRange(-8, -7)
+			lon, _ := faker.LongitudeInRange(110, 111)
+			objs = append(objs, tree.NewSpatialData(tree.NewPoint(lat, lon), []byte(fmt.Sprintf("r%dp%d", round, i))))
+			if err := rtd.Insert(objs[i]); err != nil {
+				t.Fatal(err)
+			}
+		}
+
+		faker.ShuffleAnySlice(objs)
+		for i, obj := range objs {
+			found, err := rtd.Delete(obj)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !found {
+				t.Fatalf("round %d: object %d not found", round, i)
+			}
+			if i%100 == 0 {
+				if n, err := rtd.Count(all); err != nil || n != len(objs)-i-1 {
+					t.Fatalf("round %d: expected %d objects, got %d (%v)", round, len(objs)-i-1, n, err)
+				}
+			}
+		}
+
+		results, err := rtd.Search(all, index.PredicateIntersects)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(results) != 0 {
+			t.Fatalf("round %d: expected an empty tree, got %d objects", round, len(results))
+		}
+		if found, err := rtd.Delete(objs[0]); err != nil || found {
+			t.Fatalf("round %d: delete from an empty tree: found %v, err %v", round, found, err)
+		}
+		if nearest, err := rtd.NearestNeighbors(3, tree.NewPoint(-7.5, 110.5)); err != nil || len(nearest) != 0 {
+			t.Fatalf("round %d: expected no neighbors, got %d (%v)", round, len(nearest), err)
+		}
+	}
+
+	// the shrunk tree grows again
+	for i := 0; i < 300; i++ {
+		lat, _ := faker.LatitudeInRange(-8, -7)
+		lon, _ := faker.LongitudeInRange(110, 111)
+		if err := rtd.Insert(tree.NewSpatialData(tree.NewPoint(lat, lon), []byte("again"))); err != nil {
+			t.Fatal(err)
+		}
+	}
+	results, err := rtd.Search(all, index.PredicateIntersects)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(results) != 300 {
+		t.Errorf("expected 300 objects after reinsert, got %d", len(results))
+	}
+}
+
+func TestSearchWithinRadiusAntimeridianAndPole(t *testing.T) {
+	rtd, err := index.Open(t.TempDir(), index.Options{Dim: 2, MinEntries: 4, MaxEntries: 8, MaxSpatialDataInBytes: 16})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer rtd.Close()
+
+	faker := gofakeit.New(0)
+	points := make([]tree.Point, 0, 4000)
+	for i := 0; i < 2000; i++ {
+		lat, _ := faker.LatitudeInRange(-1, 1)
+		lon, _ := faker.LongitudeInRange(178, 180)
+		if i%2 == 0 {
+			lon -= 360 - 2
+		}
+		points = append(points, tree.NewPoint(lat, lon))
+
+		lat, _ = faker.LatitudeInRange(88, 90)
+		lon, _ = faker.LongitudeInRange(-180, 180)
+		points = append(points, tree.NewPoint(lat, lon))
+	}
+	for _, p := range points {
+		if err := rtd.Insert(tree.NewSpatialData(p, []byte("p"))); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	queries := []struct {
+		p      tree.Point
+		radius float64
+	}{
+		{tree.NewPoint(0, 179.95), 50},
+		{tree.NewPoint(0.5, -179.9), 80},
+		{tree.NewPoint(89.5, 10), 100},
+		{tree.NewPoint(88.2, -170), 60},
+	}
+	for _, q := range queries {
+		want := 0
+		for _, p := range points {
+			if index.HaversineDistance(q.p.Lat, q.p.Lon, p.Lat, p.Lon) <= q.radius {
+				want++
+			}
+		}
+
+		results, err := rtd.SearchWithinRadius(q.p, q.radius, index.WithExactDistance())
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(results) != want || want == 0 {
+			t.Errorf("query %v radius %v: got %d results, want %d", q.p, q.radius, len(results), want)
+		}
+	}
+}
+
+func TestSearchReturnsPayloads(t *testing.T) {
+	rtd, err := index.Open(t.TempDir(), index.Options{Dim: 2, MinEntries: 4, MaxEntries: 8, MaxSpatialDataInBytes: 16})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer rtd.Close()
+
+	faker := gofakeit.New(0)
+	for i := 0; i < 500; i++ {
+		lat, _ := faker.LatitudeInRange(-7.818711242232534, -7.767187043571421)
+		lon, _ := faker.LongitudeInRange(110.32382482774563, 110.42872530361015)
+		if err := rtd.Insert(tree.NewSpatialData(tree.NewPoint(lat, lon), []byte(fmt.Sprintf("poi-%d", i)))); err != nil {
+			t.Fatal(err)
+		}
+	}
+	query := tree.NewPoint(-7.79, 110.37)
+
+	copied, err := rtd.SearchWithinRadius(query, 3, index.WithExactDistance())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(copied) == 0 {
+		t.Fatal("expected results")
+	}
+	for _, res := range copied {
+		if !strings.HasPrefix(string(res.Data()), "poi-") {
+			t.Errorf("unexpected payload %q", res.Data())
+		}
+	}
+
+	zeroCopy, err := rtd.SearchWithinRadius(query, 3, index.WithExactDistance(), index.WithZeroCopy())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(zeroCopy) != len(copied) {
+		t.Errorf("zero copy returned %d results, want %d", len(zeroCopy), len(copied))
+	}
+	for i := range zeroCopy {
+		if !bytes.Equal(zeroCopy[i].Data(), copied[i].Data()) {
+			t.Errorf("zero copy payload %q, want %q", zeroCopy[i].Data(), copied[i].Data())
+		}
+	}
+	rtd.Release(zeroCopy)
+
+	locations, err := rtd.SearchWithinRadius(query, 3, index.WithExactDistance(), index.WithLocationsOnly())
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, res := range locations {
+		if len(res.Data()) != 0 {
+			t.Errorf("expected no payload with WithLocationsOnly, got %q", res.Data())
+		}
+	}
+}
+
+func TestSearchPredicates(t *testing.T) {
+	rtd, err := index.Open(t.TempDir(), index.Options{Dim: 2, MinEntries: 4, MaxEntries: 8, MaxSpatialDataInBytes: 16})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer rtd.Close()
+
+	faker := gofakeit.New(0)
+	points := make([]tree.Point, 0, 3000)
+	for i := 0; i < 3000; i++ {
+		lat, _ := faker.LatitudeInRange(-8, -6)
+		lon, _ := faker.LongitudeInRange(106, 112)
+		points = append(points, tree.NewPoint(lat, lon))
+		if err := rtd.Insert(tree.NewSpatialData(points[i], []byte("p"))); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	q := tree.NewRectFromBounds(-7.5, 107, -6.8, 110)
+	cases := []struct {
+		pred  index.Predicate
+		match func(e tree.Rect) bool
+	}{
+		{index.PredicateIntersects, func(e tree.Rect) bool { return e.Overlaps(q) }},
+		{index.PredicateWithin, func(e tree.Rect) bool { return q.ContainRect(e) }},
+		{index.PredicateDisjoint, func(e tree.Rect) bool { return !e.Overlaps(q) }},
+	}
+	for _, c := range cases {
+		want := 0
+		for _, p := range points {
+			if c.match(p.ToRect(0)) {
+				want++
+			}
+		}
+
+		results, err := rtd.Search(q, c.pred)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(results) != want || want == 0 {
+			t.Errorf("predicate %d: got %d results, want %d", c.pred, len(results), want)
+		}
+		for _, res := range results {
+			if !c.match(res.Extent()) {
+				t.Errorf("predicate %d: result %v does not match", c.pred, res.Location())
+			}
+		}
+	}
+
+	// point object hanya contain query rect yang degenerate di titik itu sendiri
+	results, err := rtd.Search(points[42].ToRect(0), index.PredicateContains)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(results) == 0 || results[0].Location() != points[42] {
+		t.Errorf("contains: got %d results, want the point %v", len(results), points[42])
+	}
+	results, err = rtd.Search(q, index.PredicateContains)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(results) != 0 {
+		t.Errorf("contains: got %d results, want 0", len(results))
+	}
+}
+
+func TestSearchIterators(t *testing.T) {
+	rtd, err := index.Open(t.TempDir(), index.Options{Dim: 2, MinEntries: 4, MaxEntries: 8, MaxSpatialDataInBytes: 16,
+		BufferPoolSizeInMB: 1})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer rtd.Close()
+
+	faker := gofakeit.New(0)
+	points := make([]tree.Point, 0, 3000)
+	for i := 0; i < 3000; i++ {
+		lat, _ := faker.LatitudeInRange(-8, -6)
+		lon, _ := faker.LongitudeInRange(106, 112)
+		points = append(points, tree.NewPoint(lat, lon))
+		if err := rtd.Insert(tree.NewSpatialData(points[i], []byte(fmt.Sprintf("p%d", i)))); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	q := tree.NewRectFromBounds(-7.5, 107, -6.8, 110)
+	want, err := rtd.Search(q, index.PredicateWithin)
+	if err != nil {
+		t.Fatal(err)
+	}
+	got := 0
+	for obj, err := range rtd.SearchIter(q, index.PredicateWithin) {
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !q.ContainRect(obj.Extent()) || !strings.HasPrefix(string(obj.Data()), "p") {
+			t.Errorf("unexpected object %v %q", obj.Location(), obj.Data())
+		}
+		got++
+	}
+	if got != len(want) {
+		t.Errorf("SearchIter: got %d objects, want %d", got, len(want))
+	}
+
+	center := tree.NewPoint(-7.7675, 110.3763)
+	wantRadius, err := rtd.SearchWithinRadius(center, 20, index.WithExactDistance())
+	if err != nil {
+		t.Fatal(err)
+	}
+	got = 0
+	for _, err := range rtd.SearchWithinRadiusIter(center, 20, index.WithExactDistance()) {
+		if err != nil {
+			t.Fatal(err)
+		}
+		got++
+	}
+	if got != len(wantRadius) {
+		t.Errorf("SearchWithinRadiusIter: got %d objects, want %d", got, len(wantRadius))
+	}
+
+	// breaking out early must unpin the pages, otherwise the small buffer pool runs out of frames.
+	for i := 0; i < 2000; i++ {
+		for _, err := range rtd.SearchIter(q, index.PredicateIntersects) {
+			if err != nil {
+				t.Fatal(err)
+			}
+			break
+		}
+		for _, err := range rtd.NearestNeighborsIter(center) {
+			if err != nil {
+				t.Fatal(err)
+			}
+			break
+		}
+	}
+
+	dists := make([]float64, len(points))
+	for i, p := range points {
+		dists[i] = index.HaversineDistance(center.Lat, center.Lon, p.Lat, p.Lon)
+	}
+	sort.Float64s(dists)
+	i := 0
+	for obj, err := range rtd.NearestNeighborsIter(center) {
+		if err != nil {
+			t.Fatal(err)
+		}
+		dist := index.HaversineDistance(center.Lat, center.Lon, obj.Location().Lat, obj.Location().Lon)
+		if math.Abs(dist-dists[i]) > 1e-9 {
+			t.Errorf("neighbor %d: got distance %v, want %v", i, dist, dists[i])
+		}
+		i++
+		if i == 50 {
+			break
+		}
+	}
+}
+
+func TestNearestNeighborsGeodesic(t *testing.T) {
+	rtd, err := index.Open(t.TempDir(), index.Options{Dim: 2, MinEntries: 4, MaxEntries: 8, MaxSpatialDataInBytes: 16})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer rtd.Close()
+
+	faker := gofakeit.New(0)
+	points := make([]tree.Point, 0, 3000)
+	for i := 0; i < 1000; i++ {
+		lat, _ := faker.LatitudeInRange(-70, 70)
+		lon, _ := faker.LongitudeInRange(170, 180)
+		if i%2 == 0 {
+			lon -= 350
+		}
+		points = append(points, tree.NewPoint(lat, lon))
+
+		lat, _ = faker.LatitudeInRange(80, 90)
+		lon, _ = faker.LongitudeInRange(-180, 180)
+		points = append(points, tree.NewPoint(lat, lon))
+
+		lat = faker.Latitude()
+		lon = faker.Longitude()
+		points = append(points, tree.NewPoint(lat, lon))
+	}
+	for _, p := range points {
+		if err := rtd.Insert(tree.NewSpatialData(p, []byte("p"))); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	queries := []tree.Point{
+		tree.NewPoint(0, 179.9),
+		tree.NewPoint(-30, -179.5),
+		tree.NewPoint(89, 0),
+		tree.NewPoint(60, 100),
+		tree.NewPoint(-7.7675, 110.3763),
+	}
+	const k = 30
+	for _, q := range queries {
+		dists := make([]float64, len(points))
+		for i, p := range points {
+			dists[i] = index.HaversineDistance(q.Lat, q.Lon, p.Lat, p.Lon)
+		}
+		sort.Float64s(dists)
+
+		results, err := rtd.NearestNeighbors(k, q)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(results) != k {
+			t.Fatalf("query %v: got %d results, want %d", q, len(results), k)
+		}
+		for i, res := range results {
+			if math.Abs(res.Distance-dists[i]) > 1e-9 {
+				t.Errorf("query %v neighbor %d: got distance %v, want %v", q, i, res.Distance, dists[i])
+			}
+		}
+	}
+
+	it := rtd.Nearest(queries[0])
+	n := 0
+	prev := 0.0
+	for {
+		res, ok, err := it.NextNearest()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !ok {
+			break
+		}
+		if res.Distance < prev {
+			t.Fatalf("NextNearest out of order: %v after %v", res.Distance, prev)
+		}
+		prev = res.Distance
+		n++
+	}
+	if n != len(points) {
+		t.Errorf("NextNearest: got %d objects, want %d", n, len(points))
+	}
+
+	for _, k := range []int{-1, 0} {
+		results, err := rtd.NearestNeighbors(k, queries[0])
+		if err != nil || len(results) != 0 {
+			t.Errorf("k %d: expected no results, got %d (%v)", k, len(results), err)
+		}
+	}
+	results, err := rtd.NearestNeighbors(math.MaxInt, queries[0])
+	if err != nil || len(results) != len(points) {
+		t.Errorf("k MaxInt: expected %d results, got %d (%v)", len(points), len(results), err)
+	}
+}
+
+func TestNearestNeighborsMaxDistanceAndFilter(t *testing.T) {
+	rtd, err := index.Open(t.TempDir(), index.Options{Dim: 2, MinEntries: 4, MaxEntries: 8, MaxSpatialDataInBytes: 16})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer rtd.Close()
+
+	faker := gofakeit.New(0)
+	type driver struct {
+		p         tree.Point
+		available bool
+	}
+	drivers := make([]driver, 0, 3000)
+	for i := 0; i < 3000; i++ {
+		lat, _ := faker.LatitudeInRange(-7.85, -7.70)
+		lon, _ := faker.LongitudeInRange(110.30, 110.45)
+		d := driver{tree.NewPoint(lat, lon), i%7 == 0}
+		drivers = append(drivers, d)
+
+		status := "busy"
+		if d.available {
+			status = "available"
+		}
+		if err := rtd.Insert(tree.NewSpatialData(d.p, []byte(status))); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	center := tree.NewPoint(-7.7675, 110.3763)
+	available := func(obj tree.SpatialData) bool {
+		return bytes.Equal(obj.Data(), []byte("available"))
+	}
+
+	for _, c := range []struct {
+		k      int
+		maxKM  float64
+		filter bool
+	}{
+		{5, 3, true},
+		{5, 0.3, true},
+		{50, 1, false},
+		{10, 0, true},
+	} {
+		want := []float64{}
+		for _, d := range drivers {
+			dist := index.HaversineDistance(center.Lat, center.Lon, d.p.Lat, d.p.Lon)
+			if (c.maxKM > 0 && dist > c.maxKM) || (c.filter && !d.available) {
+				continue
+			}
+			want = append(want, dist)
+		}
+		sort.Float64s(want)
+		if len(want) > c.k {
+			want = want[:c.k]
+		}
+
+		opts := []index.SearchOption{index.WithMaxDistance(c.maxKM)}
+		if c.filter {
+			opts = append(opts, index.WithFilter(available))
+		}
+		results, err := rtd.NearestNeighbors(c.k, center, opts...)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(results) != len(want) {
+			t.Fatalf("k %d max %v: got %d results, want %d", c.k, c.maxKM, len(results), len(want))
+		}
+		for i, res := range results {
+			if math.Abs(res.Distance-want[i]) > 1e-9 || (c.filter && string(res.Data()) != "available") {
+				t.Errorf("k %d max %v neighbor %d: got %v %q, want distance %v", c.k, c.maxKM, i, res.Distance, res.Data(), want[i])
+			}
+		}
+	}
+
+	results, err := rtd.SearchWithinRadius(center, 2, index.WithExactDistance(), index.WithFilter(available))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, res := range results {
+		if string(res.Data()) != "available" {
+			t.Errorf("SearchWithinRadius with filter returned %q", res.Data())
+		}
+	}
+}
+
+func TestSearchPolygon(t *testing.T) {
+	rtd, err := index.Open(t.TempDir(), index.Options{Dim: 2, MinEntries: 4, MaxEntries: 8, MaxSpatialDataInBytes: 16})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer rtd.Close()
+
+	faker := gofakeit.New(0)
+	points := make([]tree.Point, 0, 3000)
+	for i := 0; i < 3000; i++ {
+		lat, _ := faker.LatitudeInRange(-8, -6)
+		lon, _ := faker.LongitudeInRange(106, 112)
+		points = append(points, tree.NewPoint(lat, lon))
+		if err := rtd.Insert(tree.NewSpatialData(points[i], []byte("p"))); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// zona berbentuk U dengan hole persegi di kaki kiri
+	zone := tree.NewPolygon(
+		[]tree.Point{
+			tree.NewPoint(-7.8, 107), tree.NewPoint(-7.8, 111), tree.NewPoint(-6.2, 111), tree.NewPoint(-6.2, 110),
+			tree.NewPoint(-7.2, 110), tree.NewPoint(-7.2, 108), tree.NewPoint(-6.2, 108), tree.NewPoint(-6.2, 107),
+			tree.NewPoint(-7.8, 107),
+		},
+		[]tree.Point{
+			tree.NewPoint(-7.0, 107.2), tree.NewPoint(-7.0, 107.8), tree.NewPoint(-6.5, 107.8), tree.NewPoint(-6.5, 107.2),
+		},
+	)
+
+	for _, c := range []struct {
+		p    tree.Point
+		want bool
+	}{
+		{tree.NewPoint(-7.5, 109), true},
+		{tree.NewPoint(-6.5, 109), false},   // di lekukan U
+		{tree.NewPoint(-6.8, 107.5), false}, // di dalam hole
+		{tree.NewPoint(-6.5, 107.1), true},
+		{tree.NewPoint(-7.8, 109), true}, // di edge
+	} {
+		if got := zone.ContainsPoint(c.p); got != c.want {
+			t.Errorf("ContainsPoint(%v) = %v, want %v", c.p, got, c.want)
+		}
+	}
+
+	want := 0
+	for _, p := range points {
+		if zone.ContainsPoint(p) {
+			want++
+		}
+	}
+	results, err := rtd.SearchPolygon(zone)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(results) != want || want == 0 {
+		t.Errorf("SearchPolygon: got %d results, want %d", len(results), want)
+	}
+	for _, res := range results {
+		if !zone.ContainsPoint(res.Location()) {
+			t.Errorf("SearchPolygon returned %v outside the zone", res.Location())
+		}
+	}
+
+	got := 0
+	for _, err := range rtd.SearchPolygonIter(zone) {
+		if err != nil {
+			t.Fatal(err)
+		}
+		got++
+	}
+	if got != want {
+		t.Errorf("SearchPolygonIter: got %d objects, want %d", got, want)
+	}
+}
+
+// interpolateGreatCircle. titik di fraksi f sepanjang great circle a-b.
+func interpolateGreatCircle(a, b tree.Point, f float64) tree.Point {
+	toRad := math.Pi / 180
+	latA, lonA, latB, lonB := a.Lat*toRad, a.Lon*toRad, b.Lat*toRad, b.Lon*toRad
+	d := index.HaversineDistance(a.Lat, a.Lon, b.Lat, b.Lon) / 6371.0
+	if d == 0 {
+		return a
+	}
+	wa, wb := math.Sin((1-f)*d)/math.Sin(d), math.Sin(f*d)/math.Sin(d)
+	x := wa*math.Cos(latA)*math.Cos(lonA) + wb*math.Cos(latB)*math.Cos(lonB)
+	y := wa*math.Cos(latA)*math.Sin(lonA) + wb*math.Cos(latB)*math.Sin(lonB)
+	z := wa*math.Sin(latA) + wb*math.Sin(latB)
+	return tree.NewPoint(math.Atan2(z, math.Hypot(x, y))/toRad, math.Atan2(y, x)/toRad)
+}
+
+func TestSearchAlongPath(t *testing.T) {
+	rtd, err := index.Open(t.TempDir(), index.Options{Dim: 2, MinEntries: 4, MaxEntries: 8, MaxSpatialDataInBytes: 16})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer rtd.Close()
+
+	routes := []struct {
+		path     []tree.Point
+		distance float64
+	}{
+		// rute pendek di jogja, 200 m
+		{[]tree.Point{tree.NewPoint(-7.7829, 110.3671), tree.NewPoint(-7.7750, 110.3750), tree.NewPoint(-7.7675, 110.3763),
+			tree.NewPoint(-7.7600, 110.3900)}, 0.2},
+		// great circle yang vertex nya lebih utara dari kedua ujungnya
+		{[]tree.Point{tree.NewPoint(60, -40), tree.NewPoint(60, 60)}, 50},
+		// lewat antimeridian
+		{[]tree.Point{tree.NewPoint(-15, 170), tree.NewPoint(-20, -170), tree.NewPoint(-10, -160)}, 30},
+	}
+
+	faker := gofakeit.New(0)
+	points := make([]tree.Point, 0, 6000)
+	for _, r := range routes {
+		for i := 0; i < 2000; i++ {
+			seg := faker.IntRange(0, len(r.path)-2)
+			base := interpolateGreatCircle(r.path[seg], r.path[seg+1], faker.Float64Range(0, 1))
+			jitter := r.distance / 111.0 * 3
+			lat := base.Lat + faker.Float64Range(-jitter, jitter)
+			lon := base.Lon + faker.Float64Range(-jitter, jitter)/math.Cos(base.Lat*math.Pi/180)
+			if lon > 180 {
+				lon -= 360
+			} else if lon < -180 {
+				lon += 360
+			}
+			points = append(points, tree.NewPoint(lat, lon))
+		}
+	}
+	for _, p := range points {
+		if err := rtd.Insert(tree.NewSpatialData(p, []byte("poi"))); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	for ri, r := range routes {
+		// jarak ke rute dicari dengan ternary search sepanjang tiap segment
+		approx := func(p tree.Point) float64 {
+			best := math.Inf(1)
+			for i := 0; i+1 < len(r.path); i++ {
+				distAt := func(f float64) float64 {
+					q := interpolateGreatCircle(r.path[i], r.path[i+1], f)
+					return index.HaversineDistance(p.Lat, p.Lon, q.Lat, q.Lon)
+				}
+				lo, hi := 0.0, 1.0
+				for it := 0; it < 60; it++ {
+					m1, m2 := lo+(hi-lo)/3, hi-(hi-lo)/3
+					if distAt(m1) < distAt(m2) {
+						hi = m2
+					} else {
+						lo = m1
+					}
+				}
+				best = math.Min(best, distAt((lo+hi)/2))
+			}
+			return best
+		}
+
+		results, err := rtd.SearchAlongPath(r.path, r.distance, index.WithSortByDistance())
+		if err != nil {
+			t.Fatal(err)
+		}
+		found := make(map[tree.Point]float64, len(results))
+		for _, res := range results {
+			found[res.Location()] = res.Distance
+		}
+
+		tol := r.distance * 0.001
+		inside := 0
+		for _, p := range points {
+			dist, ok := found[p]
+			want := approx(p)
+			if want <= r.distance-tol && !ok {
+				t.Errorf("route %d: %v at %v km missing", ri, p, want)
+			}
+			if want >= r.distance+tol && ok {
+				t.Errorf("route %d: %v at %v km should not be returned", ri, p, want)
+			}
+			if ok {
+				inside++
+				if math.Abs(dist-want) > tol {
+					t.Errorf("route %d: %v distance %v, want about %v", ri, p, dist, want)
+				}
+			}
+		}
+		if inside == 0 {
+			t.Errorf("route %d: no results", ri)
+		}
+		for i := 1; i < len(results); i++ {
+			if results[i].Distance < results[i-1].Distance {
+				t.Fatalf("route %d: results not sorted by distance", ri)
+			}
+		}
+	}
+}
+
+func TestJoin(t *testing.T) {
+	stores, err := index.Open(t.TempDir(), index.Options{Dim: 2, MinEntries: 4, MaxEntries: 8, MaxSpatialDataInBytes: 16})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer stores.Close()
+	customers, err := index.Open(t.TempDir(), index.Options{Dim: 2, MinEntries: 10, MaxEntries: 20, MaxSpatialDataInBytes: 16,
+		BufferPoolSizeInMB: 1})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer customers.Close()
+
+	faker := gofakeit.New(0)
+	randomPoints := func(n int) []tree.Point {
+		points := make([]tree.Point, n)
+		for i := range points {
+			lat, _ := faker.LatitudeInRange(-7.90, -7.65)
+			lon, _ := faker.LongitudeInRange(110.25, 110.50)
+			points[i] = tree.NewPoint(lat, lon)
+		}
+		return points
+	}
+	storePoints, customerPoints := randomPoints(300), randomPoints(4000)
+	for i, p := range storePoints {
+		if err := stores.Insert(tree.NewSpatialData(p, []byte(fmt.Sprintf("s%d", i)))); err != nil {
+			t.Fatal(err)
+		}
+	}
+	for i, p := range customerPoints {
+		if err := customers.Insert(tree.NewSpatialData(p, []byte(fmt.Sprintf("c%d", i)))); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	const distance = 1.5
+	want := 0
+	for _, s := range storePoints {
+		for _, c := range customerPoints {
+			if index.HaversineDistance(s.Lat, s.Lon, c.Lat, c.Lon) <= distance {
+				want++
+			}
+		}
+	}
+
+	got := 0
+	seen := make(map[string]bool)
+	for pair, err := range index.Join(stores, customers, distance) {
+		if err != nil {
+			t.Fatal(err)
+		}
+		key := string(pair.A.Data()) + "-" + string(pair.B.Data())
+		if seen[key] {
+			t.Errorf("pair %s yielded twice", key)
+		}
+		seen[key] = true
+		a, b := pair.A.Location(), pair.B.Location()
+		if d := index.HaversineDistance(a.Lat, a.Lon, b.Lat, b.Lon); d > distance || math.Abs(d-pair.Distance) > 1e-9 {
+			t.Errorf("pair %s: distance %v, reported %v", key, d, pair.Distance)
+		}
+		got++
+	}
+	if got != want || want == 0 {
+		t.Errorf("Join: got %d pairs, want %d", got, want)
+	}
+
+	// berhenti di tengah tidak boleh meninggalkan page yang masih di pin
+	for i := 0; i < 2000; i++ {
+		for _, err := range index.Join(customers, stores, distance) {
+			if err != nil {
+				t.Fatal(err)
+			}
+			break
+		}
+	}
+}
+
+func TestClosestPairsAndKNNJoin(t *testing.T) {
+	couriers, err := index.Open(t.TempDir(), index.Options{Dim: 2, MinEntries: 4, MaxEntries: 8, MaxSpatialDataInBytes: 16})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer couriers.Close()
+	orders, err := index.Open(t.TempDir(), index.Options{Dim: 2, MinEntries: 10, MaxEntries: 20, MaxSpatialDataInBytes: 16})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer orders.Close()
+
+	faker := gofakeit.New(0)
+	// sebagian di jogja, sebagian di sekitar antimeridian dekat kutub utara
+	randomPoints := func(n int) []tree.Point {
+		points := make([]tree.Point, n)
+		for i := range points {
+			if i%2 == 0 {
+				lat, _ := faker.LatitudeInRange(-7.90, -7.65)
+				lon, _ := faker.LongitudeInRange(110.25, 110.50)
+				points[i] = tree.NewPoint(lat, lon)
+				continue
+			}
+			lat, _ := faker.LatitudeInRange(64, 66)
+			lon, _ := faker.LongitudeInRange(175, 185)
+			if lon > 180 {
+				lon -= 360
+			}
+			points[i] = tree.NewPoint(lat, lon)
+		}
+		return points
+	}
+	courierPoints, orderPoints := randomPoints(400), randomPoints(3000)
+	for i, p := range courierPoints {
+		if err := couriers.Insert(tree.NewSpatialData(p, []byte(fmt.Sprintf("c%d", i)))); err != nil {
+			t.Fatal(err)
+		}
+	}
+	for i, p := range orderPoints {
+		if err := orders.Insert(tree.NewSpatialData(p, []byte(fmt.Sprintf("o%d", i)))); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	all := make([]float64, 0, len(courierPoints)*len(orderPoints))
+	nearest := make(map[tree.Point][]float64, len(courierPoints))
+	for _, c := range courierPoints {
+		dists := make([]float64, len(orderPoints))
+		for j, o := range orderPoints {
+			dists[j] = index.HaversineDistance(c.Lat, c.Lon, o.Lat, o.Lon)
+		}
+		all = append(all, dists...)
+		sort.Float64s(dists)
+		nearest[c] = dists[:3]
+	}
+	sort.Float64s(all)
+
+	const k = 25
+	pairs, err := index.ClosestPairs(couriers, orders, k)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(pairs) != k {
+		t.Fatalf("ClosestPairs: got %d pairs, want %d", len(pairs), k)
+	}
+	for i, pair := range pairs {
+		if math.Abs(pair.Distance-all[i]) > 1e-9 || !strings.HasPrefix(string(pair.A.Data()), "c") ||
+			!strings.HasPrefix(string(pair.B.Data()), "o") {
+			t.Errorf("pair %d: got %q-%q at %v, want distance %v", i, pair.A.Data(), pair.B.Data(), pair.Distance, all[i])
+		}
+	}
+
+	got := 0
+	for n, err := range index.KNNJoin(couriers, orders, 3) {
+		if err != nil {
+			t.Fatal(err)
+		}
+		want := nearest[n.Location()]
+		if len(n.Nearest) != len(want) {
+			t.Fatalf("courier %q: got %d neighbors, want %d", n.Data(), len(n.Nearest), len(want))
+		}
+		for i, res := range n.Nearest {
+			if math.Abs(res.Distance-want[i]) > 1e-9 {
+				t.Errorf("courier %q neighbor %d: got distance %v, want %v", n.Data(), i, res.Distance, want[i])
+			}
+		}
+		got++
+	}
+	if got != len(courierPoints) {
+		t.Errorf("KNNJoin: got %d couriers, want %d", got, len(courierPoints))
+	}
+
+	for _, k := range []int{-1, 0} {
+		pairs, err := index.ClosestPairs(couriers, orders, k)
+		if err != nil || len(pairs) != 0 {
+			t.Errorf("ClosestPairs k %d: expected no pairs, got %d (%v)", k, len(pairs), err)
+		}
+		got = 0
+		for n, err := range index.KNNJoin(couriers, orders, k) {
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(n.Nearest) != 0 {
+				t.Errorf("KNNJoin k %d: courier %q got %d neighbors, want none", k, n.Data(), len(n.Nearest))
+			}
+			got++
+		}
+		if got != len(courierPoints) {
+			t.Errorf("KNNJoin k %d: got %d couriers, want %d", k, got, len(courierPoints))
+		}
+	}
+}
+
+func TestCount(t *testing.T) {
+	rtd, err := index.Open(t.TempDir(), index.Options{Dim: 2, MinEntries: 4, MaxEntries: 8, MaxSpatialDataInBytes: 16})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer rtd.Close()
+
+	faker := gofakeit.New(0)
+	objs := make([]tree.SpatialData, 0, 4000)
+	for i := 0; i < 4000; i++ {
+		lat, _ := faker.LatitudeInRange(-8, -6)
+		lon, _ := faker.LongitudeInRange(106, 112)
+		obj := tree.NewSpatialData(tree.NewPoint(lat, lon), []byte(fmt.Sprintf("p%d", i)))
+		objs = append(objs, obj)
+		if err := rtd.Insert(obj); err != nil {
+			t.Fatal(err)
+		}
+	}
+	// hapus sebagian supaya count ikut di update lewat condenseTree & reinsert
+	for _, obj := range objs[:1500] {
+		if ok, err := rtd.Delete(obj); err != nil || !ok {
+			t.Fatalf("delete %q: %v %v", obj.Data(), ok, err)
+		}
+	}
+	objs = objs[1500:]
+
+	rects := []tree.Rect{
+		tree.NewRectFromBounds(-7.5, 107, -6.8, 110),
+		tree.NewRectFromBounds(-8, 106, -6, 112),
+		tree.NewRectFromBounds(-7.01, 108.5, -7, 108.51),
+		tree.NewRectFromBounds(10, 10, 11, 11),
+	}
+	for _, rect := range rects {
+		want := 0
+		for _, obj := range objs {
+			if rect.Overlaps(obj.Extent()) {
+				want++
+			}
+		}
+		got, err := rtd.Count(rect)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got != want {
+			t.Errorf("Count(%v) = %d, want %d", rect, got, want)
+		}
+	}
+}
+
+func TestAggregate(t *testing.T) {
+	rtd, err := index.Open(t.TempDir(), index.Options{Dim: 2, MinEntries: 4, MaxEntries: 8, MaxSpatialDataInBytes: 16})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer rtd.Close()
+
+	faker := gofakeit.New(0)
+	points := make([]tree.Point, 0, 5000)
+	for i := 0; i < 5000; i++ {
+		lat, _ := faker.LatitudeInRange(-8, -6)
+		lon, _ := faker.LongitudeInRange(106, 112)
+		points = append(points, tree.NewPoint(lat, lon))
+		if err := rtd.Insert(tree.NewSpatialData(points[i], []byte("p"))); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	rect := tree.NewRectFromBounds(-7.6, 106.5, -6.2, 111)
+	const cellsX, cellsY = 9, 7
+	want := make([][]int, cellsY)
+	for y := range want {
+		want[y] = make([]int, cellsX)
+	}
+	for _, p := range points {
+		if !rect.ContainRect(p.ToRect(0)) {
+			continue
+		}
+		x := min(int((p.Lon-rect.GetSLon())/(rect.GetTLon()-rect.GetSLon())*cellsX), cellsX-1)
+		y := min(int((p.Lat-rect.GetSLat())/(rect.GetTLat()-rect.GetSLat())*cellsY), cellsY-1)
+		want[y][x]++
+	}
+
+	grid, err := rtd.Aggregate(rect, cellsX, cellsY)
+	if err != nil {
+		t.Fatal(err)
+	}
+	total := 0
+	for y := range want {
+		for x := range want[y] {
+			if grid[y][x] != want[y][x] {
+				t.Errorf("cell (%d, %d): got %d, want %d", x, y, grid[y][x], want[y][x])
+			}
+			total += grid[y][x]
+		}
+	}
+	if total == 0 {
+		t.Error("empty grid")
+	}
+
+	if _, err := rtd.Aggregate(rect, 0, 3); !errors.Is(err, index.ErrInvalidGrid) {
+		t.Errorf("got %v, want ErrInvalidGrid", err)
+	}
+}
+
+func TestQueryTile(t *testing.T) {
+	rtd, err := index.Open(t.TempDir(), index.Options{Dim: 2, MinEntries: 4, MaxEntries: 8, MaxSpatialDataInBytes: 16})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer rtd.Close()
+
+	faker := gofakeit.New(0)
+	points := make([]tree.Point, 0, 5000)
+	for i := 0; i < 5000; i++ {
+		lat, _ := faker.LatitudeInRange(-8, -6)
+		lon, _ := faker.LongitudeInRange(106, 112)
+		points = append(points, tree.NewPoint(lat, lon))
+		if err := rtd.Insert(tree.NewSpatialData(points[i], []byte("p"))); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// tile z/x/y yang memuat (-7, 109), koordinat tile pecahan dihitung dari rumus slippy map
+	const z = 7
+	n := float64(int(1) << z)
+	tileXY := func(p tree.Point) (float64, float64) {
+		lat := p.Lat * math.Pi / 180
+		return (p.Lon + 180) / 360 * n, (1 - math.Asinh(math.Tan(lat))/math.Pi) / 2 * n
+	}
+	fx, fy := tileXY(tree.NewPoint(-7, 109))
+	x, y := int(fx), int(fy)
+	inTile := func(p tree.Point) bool {
+		px, py := tileXY(p)
+		return px >= float64(x) && px <= float64(x+1) && py >= float64(y) && py <= float64(y+1)
+	}
+
+	want := 0
+	for _, p := range points {
+		if inTile(p) {
+			want++
+		}
+	}
+	all, err := rtd.QueryTile(z, x, y, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(all) != want || want <= 16 {
+		t.Fatalf("QueryTile without limit: got %d objects, want %d", len(all), want)
+	}
+
+	const limit = 16 // 4 x 4 sub-cells
+	thinned, err := rtd.QueryTile(z, x, y, limit)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cellOf := func(p tree.Point) int {
+		px, py := tileXY(p)
+		cx := min(int((px-float64(x))*4), 3)
+		cy := min(int((py-float64(y))*4), 3)
+		return cy*4 + cx
+	}
+	occupied := make(map[int]bool)
+	for _, p := range points {
+		if inTile(p) {
+			occupied[cellOf(p)] = true
+		}
+	}
+	seen := make(map[int]bool)
+	for _, res := range thinned {
+		if !inTile(res.Location()) {
+			t.Errorf("thinned result %v outside the tile", res.Location())
+		}
+		c := cellOf(res.Location())
+		if seen[c] {
+			t.Errorf("two results in sub-cell %d", c)
+		}
+		seen[c] = true
+	}
+	if len(seen) != len(occupied) {
+		t.Errorf("thinned results cover %d sub-cells, %d hold points", len(seen), len(occupied))
+	}
+
+	if _, err := rtd.QueryTile(3, 8, 0, 10); !errors.Is(err, index.ErrInvalidTile) {
+		t.Errorf("got %v, want ErrInvalidTile", err)
+	}
+}
+
+func TestSearchExtents(t *testing.T) {
+	dir := t.TempDir()
+	rtd, err := index.Open(dir, index.Options{Dim: 2, MinEntries: 4, MaxEntries: 8, MaxSpatialDataInBytes: 16})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	faker := gofakeit.New(0)
+	objs := make([]tree.SpatialData, 0, 1500)
+	for i := 0; i < 1500; i++ {
+		lat, _ := faker.LatitudeInRange(-8, -6)
+		lon, _ := faker.LongitudeInRange(106, 112)
+		dLat, _ := faker.LatitudeInRange(0, 0.3)
+		dLon, _ := faker.LongitudeInRange(0, 0.3)
+		data := []byte(fmt.Sprintf("o%d", i))
+
+		var obj tree.SpatialData
+		switch i % 4 {
+		case 0:
+			obj = tree.NewSpatialData(tree.NewPoint(lat, lon), data)
+		case 1:
+			// building footprint
+			obj = tree.NewSpatialDataRect(tree.NewRectFromBounds(lat, lon, lat+dLat, lon+dLon), data)
+		case 2:
+			// road segment
+			obj = tree.NewSpatialDataLine([]tree.Point{
+				tree.NewPoint(lat, lon), tree.NewPoint(lat+dLat, lon+dLon/2), tree.NewPoint(lat+dLat/2, lon+dLon),
+			}, data)
+		default:
+			obj = tree.NewSpatialDataPolygon(tree.NewPolygon([]tree.Point{
+				tree.NewPoint(lat, lon), tree.NewPoint(lat, lon+dLon), tree.NewPoint(lat+dLat, lon+dLon/2),
+			}), data)
+		}
+		objs = append(objs, obj)
+		if err := rtd.Insert(obj); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	removed := make(map[string]bool)
+	check := func(rtd *index.Rtreed) {
+		t.Helper()
+		large := tree.NewRectFromBounds(-7.5, 107.5, -6.8, 108.5)
+		// titik tengah footprint objs[1] (tidak pernah dihapus), minimal satu object contains small
+		small := objs[1].Location().ToRect(0)
+		cases := []struct {
+			pred  index.Predicate
+			q     tree.Rect
+			match func(e, q tree.Rect) bool
+		}{
+			{index.PredicateIntersects, large, func(e, q tree.Rect) bool { return e.Overlaps(q) }},
+			{index.PredicateWithin, large, func(e, q tree.Rect) bool { return q.ContainRect(e) }},
+			{index.PredicateContains, small, func(e, q tree.Rect) bool { return e.ContainRect(q) }},
+			{index.PredicateDisjoint, large, func(e, q tree.Rect) bool { return !e.Overlaps(q) }},
+		}
+		for _, c := range cases {
+			q := c.q
+			want := make(map[string]tree.GeometryType)
+			for _, obj := range objs {
+				if !removed[string(obj.Data())] && c.match(obj.Extent(), q) {
+					want[string(obj.Data())] = obj.GeometryType()
+				}
+			}
+
+			results, err := rtd.Search(q, c.pred)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(results) != len(want) || len(want) == 0 {
+				t.Errorf("predicate %d: got %d results, want %d", c.pred, len(results), len(want))
+			}
+			for _, res := range results {
+				geomType, ok := want[string(res.Data())]
+				if !ok || !c.match(res.Extent(), q) {
+					t.Errorf("predicate %d: unexpected result %s", c.pred, res.Data())
+				}
+				if res.GeometryType() != geomType {
+					t.Errorf("predicate %d: %s has geometry type %d, want %d", c.pred, res.Data(), res.GeometryType(), geomType)
+				}
+			}
+		}
+
+		// query point di dalam footprint, jaraknya ke extent 0 walaupun jauh dari titik tengahnya
+		footprint := objs[1].Extent()
+		inside := tree.NewPoint(footprint.GetSLat()+1e-6, footprint.GetSLon()+1e-6)
+		nearest, err := rtd.NearestNeighbors(1, inside, index.WithFilter(func(obj tree.SpatialData) bool {
+			return obj.GeometryType() == tree.GeometryRect
+		}))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(nearest) != 1 || nearest[0].Distance != 0 {
+			t.Errorf("nearest rect to a point inside a footprint: got %v", nearest)
+		}
+		within, err := rtd.SearchWithinRadius(inside, 0.001)
+		if err != nil {
+			t.Fatal(err)
+		}
+		found := false
+		for _, res := range within {
+			found = found || string(res.Data()) == "o1"
+		}
+		if !found {
+			t.Errorf("radius search from inside a footprint did not return it")
+		}
+	}
+	check(rtd)
+
+	for _, obj := range objs[2:40] {
+		deleted, err := rtd.Delete(obj)
+		if err != nil || !deleted {
+			t.Fatalf("delete %s: %v %v", obj.Data(), deleted, err)
+		}
+		removed[string(obj.Data())] = true
+	}
+	if err := rtd.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	rtd, err = index.Open(dir, index.Options{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer rtd.Close()
+	check(rtd)
+
+	// a line or polygon without points has an empty extent, which is rejected instead of panicking
+	for _, obj := range []tree.SpatialData{tree.NewSpatialDataLine(nil, []byte("empty")),
+		tree.NewSpatialDataPolygon(tree.NewPolygon(nil), []byte("empty"))} {
+		if !obj.Extent().IsEmpty() {
+			t.Errorf("expected an empty extent, got %v", obj.Extent())
+		}
+		if err := rtd.Insert(obj); !errors.Is(err, index.ErrEmptyGeometry) {
+			t.Errorf("expected ErrEmptyGeometry, got %v", err)
+		}
+	}
+	check(rtd)
+}
+
+func TestNDimensional(t *testing.T) {
+	dir := t.TempDir()
+	rtd, err := index.Open(dir, index.Options{Dim: 3, MinEntries: 4, MaxEntries: 8, MaxSpatialDataInBytes: 16})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// drone positions: x, y in km, altitude in km
+	faker := gofakeit.New(0)
+	points := make([]tree.Point, 0, 3000)
+	for i := 0; i < 3000; i++ {
+		p := tree.NewPointN(faker.Float64Range(0, 100), faker.Float64Range(0, 100), faker.Float64Range(0, 2))
+		points = append(points, p)
+		if err := rtd.Insert(tree.NewSpatialData(p, []byte(fmt.Sprintf("d%d", i)))); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	euclidean := func(p, q tree.Point) float64 {
+		sum := 0.0
+		for i := 0; i < p.Dims(); i++ {
+			sum += (p.Coord(i) - q.Coord(i)) * (p.Coord(i) - q.Coord(i))
+		}
+		return math.Sqrt(sum)
+	}
+
+	check := func(rtd *index.Rtreed) {
+		t.Helper()
+		box := tree.NewRectFromPoints(tree.NewPointN(20, 20, 0.5), tree.NewPointN(60, 60, 1))
+		want := 0
+		for _, p := range points {
+			if box.ContainRect(p.ToRect(0)) {
+				want++
+			}
+		}
+		results, err := rtd.Search(box, index.PredicateWithin)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(results) != want || want == 0 {
+			t.Errorf("3d box: got %d results, want %d", len(results), want)
+		}
+		for _, res := range results {
+			if loc := res.Location(); loc.Dims() != 3 || !box.ContainRect(loc.ToRect(0)) {
+				t.Errorf("3d box: result %v outside the box", loc)
+			}
+		}
+
+		q := tree.NewPointN(50, 50, 1)
+		dists := make([]float64, len(points))
+		for i, p := range points {
+			dists[i] = euclidean(q, p)
+		}
+		sort.Float64s(dists)
+
+		nearest, err := rtd.NearestNeighbors(10, q)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(nearest) != 10 {
+			t.Fatalf("got %d nearest, want 10", len(nearest))
+		}
+		for i, res := range nearest {
+			if math.Abs(res.Distance-dists[i]) > 1e-9 || math.Abs(euclidean(q, res.Location())-res.Distance) > 1e-9 {
+				t.Errorf("nearest %d: distance %v, want %v", i, res.Distance, dists[i])
+			}
+		}
+
+		within, err := rtd.SearchWithinRadius(q, 5, index.WithExactDistance())
+		if err != nil {
+			t.Fatal(err)
+		}
+		wantWithin := sort.SearchFloat64s(dists, math.Nextafter(5, 6))
+		if len(within) != wantWithin || wantWithin == 0 {
+			t.Errorf("radius: got %d results, want %d", len(within), wantWithin)
+		}
+	}
+	check(rtd)
+
+	if err := rtd.Insert(tree.NewSpatialData(tree.NewPoint(1, 1), nil)); !errors.Is(err, index.ErrDimMismatch) {
+		t.Errorf("inserting a 2d point in a 3d tree: got %v, want ErrDimMismatch", err)
+	}
+	if _, err := rtd.SearchPolygon(tree.NewPolygon([]tree.Point{tree.NewPoint(0, 0), tree.NewPoint(0, 1), tree.NewPoint(1, 1)})); !errors.Is(err, index.ErrNotGeographic) {
+		t.Errorf("polygon search in a 3d tree: got %v, want ErrNotGeographic", err)
+	}
+
+	// a missing axis is not taken as 0, 2d queries on a 3d tree fail
+	p2, rect2 := tree.NewPoint(0, 0), tree.NewRectFromBounds(0, 0, 100, 100)
+	dimErrs := map[string]error{}
+	_, dimErrs["NearestNeighbors"] = rtd.NearestNeighbors(3, p2)
+	_, _, dimErrs["NextNearest"] = rtd.Nearest(p2).NextNearest()
+	_, dimErrs["SearchWithinRadius"] = rtd.SearchWithinRadius(p2, 200)
+	_, dimErrs["Search"] = rtd.Search(rect2, index.PredicateIntersects)
+	_, dimErrs["Count"] = rtd.Count(rect2)
+	_, dimErrs["Aggregate"] = rtd.Aggregate(rect2, 2, 2)
+	for name, seq := range map[string]iter.Seq2[tree.SpatialData, error]{
+		"NearestNeighborsIter":   rtd.NearestNeighborsIter(p2),
+		"SearchWithinRadiusIter": rtd.SearchWithinRadiusIter(p2, 200),
+		"SearchIter":             rtd.SearchIter(rect2, index.PredicateIntersects),
+	} {
+		dimErrs[name] = nil
+		for _, err := range seq {
+			dimErrs[name] = err
+			break
+		}
+	}
+	for name, err := range dimErrs {
+		if !errors.Is(err, index.ErrDimMismatch) {
+			t.Errorf("2d %s on a 3d tree: got %v, want ErrDimMismatch", name, err)
+		}
+	}
+	if err := rtd.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	rtd, err = index.Open(dir, index.Options{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	check(rtd)
+	if err := rtd.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	// (x, y, altitude, time) rectangles
+	rtd4, err := index.Open(t.TempDir(), index.Options{Dim: 4, MinEntries: 2, MaxEntries: 4, MaxSpatialDataInBytes: 8})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer rtd4.Close()
+	for i := 0; i < 200; i++ {
+		lo := tree.NewPointN(float64(i), float64(i), 0, float64(i*10))
+		hi := tree.NewPointN(float64(i)+0.5, float64(i)+0.5, 1, float64(i*10+5))
+		if err := rtd4.Insert(tree.NewSpatialDataRect(tree.NewRectFromPoints(lo, hi), []byte(fmt.Sprintf("e%d", i)))); err != nil {
+			t.Fatal(err)
+		}
+	}
+	results, err := rtd4.Search(tree.NewRectFromPoints(tree.NewPointN(0, 0, 0, 52), tree.NewPointN(200, 200, 1, 52)), index.PredicateIntersects)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(results) != 1 || string(results[0].Data()) != "e5" || results[0].Extent().Dims() != 4 {
+		t.Errorf("4d time slice: got %v", results)
+	}
+}
+
+func TestTemporal(t *testing.T) {
+	dir := t.TempDir()
+	rtd, err := index.Open(dir, index.Options{MinEntries: 4, MaxEntries: 8, MaxSpatialDataInBytes: 16, Temporal: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// vehicle pings sepanjang satu hari
+	day := time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC)
+	faker := gofakeit.New(0)
+	type ping struct {
+		p  tree.Point
+		at time.Time
+	}
+	pings := make([]ping, 0, 4000)
+	for i := 0; i < 4000; i++ {
+		lat, _ := faker.LatitudeInRange(-8, -6)
+		lon, _ := faker.LongitudeInRange(106, 112)
+		at := day.Add(time.Duration(faker.IntRange(0, 24*3600-1)) * time.Second).Add(time.Duration(i) * time.Microsecond)
+		pings = append(pings, ping{tree.NewPoint(lat, lon), at})
+		obj := tree.NewSpatialData(pings[i].p, []byte(fmt.Sprintf("v%d", i))).WithTimeRange(at, at)
+		if err := rtd.Insert(obj); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	from, to := day.Add(8*time.Hour), day.Add(9*time.Hour)
+	inWindow := func(at time.Time) bool { return !at.Before(from) && !at.After(to) }
+
+	check := func(rtd *index.Rtreed) {
+		t.Helper()
+		box := tree.NewRectFromBounds(-7.5, 107, -6.5, 110)
+		want := 0
+		for _, pg := range pings {
+			if box.ContainRect(pg.p.ToRect(0)) && inWindow(pg.at) {
+				want++
+			}
+		}
+		results, err := rtd.Search(box, index.PredicateIntersects, index.WithTimeRange(from, to))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(results) != want || want == 0 {
+			t.Errorf("box between 08:00 and 09:00: got %d results, want %d", len(results), want)
+		}
+		for _, res := range results {
+			start, end, ok := res.TimeRange()
+			if !ok || !start.Equal(end) || !inWindow(start) {
+				t.Errorf("result %s has time range %v..%v (%v)", res.Data(), start, end, ok)
+			}
+			var i int
+			fmt.Sscanf(string(res.Data()), "v%d", &i)
+			if !start.Equal(pings[i].at) {
+				t.Errorf("result %s: time %v, want %v", res.Data(), start, pings[i].at)
+			}
+		}
+
+		q := tree.NewPoint(-7, 109)
+		dists := make([]float64, 0, len(pings))
+		for _, pg := range pings {
+			if inWindow(pg.at) {
+				dists = append(dists, index.HaversineDistance(q.Lat, q.Lon, pg.p.Lat, pg.p.Lon))
+			}
+		}
+		sort.Float64s(dists)
+		nearest, err := rtd.NearestNeighbors(5, q, index.WithTimeRange(from, to))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(nearest) != 5 {
+			t.Fatalf("got %d nearest, want 5", len(nearest))
+		}
+		for i, res := range nearest {
+			start, _, _ := res.TimeRange()
+			if math.Abs(res.Distance-dists[i]) > 1e-9 || !inWindow(start) {
+				t.Errorf("nearest %d: distance %v at %v, want %v", i, res.Distance, start, dists[i])
+			}
+		}
+	}
+	check(rtd)
+
+	if err := rtd.Insert(tree.NewSpatialData(tree.NewPoint(-7, 110), nil)); !errors.Is(err, index.ErrTimeRange) {
+		t.Errorf("inserting an object without time range: got %v, want ErrTimeRange", err)
+	}
+	inverted := tree.NewSpatialData(tree.NewPoint(-7, 110), nil).WithTimeRange(to, from)
+	if err := rtd.Insert(inverted); !errors.Is(err, index.ErrTimeRange) {
+		t.Errorf("inserting an object ending before it starts: got %v, want ErrTimeRange", err)
+	}
+	box := tree.NewRectFromBounds(-7.5, 107, -6.5, 110)
+	forward, err := rtd.Search(box, index.PredicateIntersects, index.WithTimeRange(from, to))
+	if err != nil {
+		t.Fatal(err)
+	}
+	backward, err := rtd.Search(box, index.PredicateIntersects, index.WithTimeRange(to, from))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(backward) != len(forward) || len(forward) == 0 {
+		t.Errorf("window 09:00..08:00: got %d results, 08:00..09:00 got %d", len(backward), len(forward))
+	}
+	if err := rtd.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	rtd, err = index.Open(dir, index.Options{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	check(rtd)
+	if err := rtd.Close(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestObjectIDs(t *testing.T) {
+	dir := t.TempDir()
+	rtd, err := index.Open(dir, index.Options{MinEntries: 4, MaxEntries: 8, MaxSpatialDataInBytes: 16})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// semua object payload nya sama, cuma bisa dibedakan lewat id
+	faker := gofakeit.New(0)
+	locations := make(map[uint64]tree.Point, 3000)
+	for i := 0; i < 3000; i++ {
+		lat, _ := faker.LatitudeInRange(-7.818711242232534, -7.767187043571421)
+		lon, _ := faker.LongitudeInRange(110.32382482774563, 110.42872530361015)
+		id, err := rtd.InsertID(tree.NewSpatialData(tree.NewPoint(lat, lon), []byte("halte")))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, ok := locations[id]; ok || id == 0 {
+			t.Fatalf("insert %d got id %d twice or zero", i, id)
+		}
+		locations[id] = tree.NewPoint(lat, lon)
+	}
+
+	for id := range locations {
+		if id%3 != 0 {
+			continue
+		}
+		found, err := rtd.DeleteByID(id)
+		if err != nil || !found {
+			t.Fatalf("DeleteByID(%d): %v %v", id, found, err)
+		}
+		delete(locations, id)
+		if found, err := rtd.DeleteByID(id); err != nil || found {
+			t.Fatalf("deleting %d twice: %v %v", id, found, err)
+		}
+	}
+	for id := range locations {
+		if id%5 != 0 {
+			continue
+		}
+		p := tree.NewPoint(locations[id].Lat+0.05, locations[id].Lon)
+		if err := rtd.Move(id, p); err != nil {
+			t.Fatalf("Move(%d): %v", id, err)
+		}
+		locations[id] = p
+	}
+
+	check := func(rtd *index.Rtreed) {
+		t.Helper()
+		for id, p := range locations {
+			obj, err := rtd.Get(id)
+			if err != nil {
+				t.Fatalf("Get(%d): %v", id, err)
+			}
+			if obj.ID() != id || obj.Location() != p || string(obj.Data()) != "halte" {
+				t.Errorf("Get(%d) = %d at %v (%s), want %v", id, obj.ID(), obj.Location(), obj.Data(), p)
+			}
+		}
+		if _, err := rtd.Get(3); !errors.Is(err, index.ErrObjectNotFound) {
+			t.Errorf("Get of a deleted id: got %v, want ErrObjectNotFound", err)
+		}
+
+		results, err := rtd.Search(tree.NewRectFromBounds(-8, 110, -7, 111), index.PredicateIntersects)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(results) != len(locations) {
+			t.Errorf("got %d objects, want %d", len(results), len(locations))
+		}
+		for _, res := range results {
+			if p, ok := locations[res.ID()]; !ok || res.Location() != p {
+				t.Errorf("result with id %d at %v, want %v (%v)", res.ID(), res.Location(), p, ok)
+			}
+		}
+	}
+	check(rtd)
+
+	if err := rtd.Close(); err != nil {
+		t.Fatal(err)
+	}
+	rtd, err = index.Open(dir, index.Options{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	check(rtd)
+
+	id, err := rtd.InsertID(tree.NewSpatialData(tree.NewPoint(-7.8, 110.4), []byte("halte")))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if id <= 3000 {
+		t.Errorf("id after reopen %d, want a fresh one", id)
+	}
+	if err := rtd.UpdateByID(id, tree.NewSpatialData(tree.NewPoint(-7.7, 110.4), []byte("baru"))); err != nil {
+		t.Fatal(err)
+	}
+	if obj, err := rtd.Get(id); err != nil || string(obj.Data()) != "baru" {
+		t.Errorf("Get after UpdateByID: %s %v", obj.Data(), err)
+	}
+	if err := rtd.Close(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestRStar(t *testing.T) {
+	dir, guttmanDir := t.TempDir(), t.TempDir()
+
+	// titik menumpuk di sekitar beberapa pusat kota
+	faker := gofakeit.New(0)
+	centers := []tree.Point{tree.NewPoint(-7.79, 110.37), tree.NewPoint(-7.57, 110.82), tree.NewPoint(-6.99, 110.42)}
+	points := make(map[uint64]tree.Point, 4000)
+	for i := 0; i < 4000; i++ {
+		c := centers[i%len(centers)]
+		// id mengikuti urutan insert
+		points[uint64(i+1)] = tree.NewPoint(c.Lat+faker.Float64Range(-0.02, 0.02), c.Lon+faker.Float64Range(-0.02, 0.02))
+	}
+
+	// data yang sama diinsert ke tree Guttman, leaf tree R* harus jauh lebih sedikit overlap nya
+	for _, d := range []struct {
+		dir      string
+		strategy index.Strategy
+	}{{dir, index.StrategyRStar}, {guttmanDir, index.StrategyGuttman}} {
+		rtd, err := index.Open(d.dir, index.Options{MinEntries: 4, MaxEntries: 10, MaxSpatialDataInBytes: 16,
+			PageSize: 4096, Strategy: d.strategy})
+		if err != nil {
+			t.Fatal(err)
+		}
+		for id := uint64(1); id <= uint64(len(points)); id++ {
+			if _, err := rtd.InsertID(tree.NewSpatialData(points[id], []byte(fmt.Sprintf("p%d", id-1)))); err != nil {
+				t.Fatal(err)
+			}
+		}
+		if err := rtd.Close(); err != nil {
+			t.Fatal(err)
+		}
+	}
+	rstarOverlap := leafOverlap(t, filepath.Join(dir, "go_rtreed.page"), 4096)
+	guttmanOverlap := leafOverlap(t, filepath.Join(guttmanDir, "go_rtreed.page"), 4096)
+	if rstarOverlap*4 > guttmanOverlap {
+		t.Errorf("overlap between R* leaves %v, between Guttman leaves %v, want under 25%%", rstarOverlap, guttmanOverlap)
+	}
+
+	rtd, err := index.Open(dir, index.Options{Strategy: index.StrategyRStar})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for id := range points {
+		if id%4 == 0 {
+			if found, err := rtd.DeleteByID(id); err != nil || !found {
+				t.Fatalf("DeleteByID(%d): %v %v", id, found, err)
+			}
+			delete(points, id)
+		}
+	}
+
+	check := func(rtd *index.Rtreed) {
+		t.Helper()
+		for _, c := range centers {
+			q := tree.NewPoint(c.Lat+0.005, c.Lon-0.005)
+			want := 0
+			for _, p := range points {
+				if index.HaversineDistance(q.Lat, q.Lon, p.Lat, p.Lon) <= 0.5 {
+					want++
+				}
+			}
+			results, err := rtd.SearchWithinRadius(q, 0.5, index.WithExactDistance())
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(results) != want || want == 0 {
+				t.Errorf("radius around %v: got %d results, want %d", q, len(results), want)
+			}
+			for _, res := range results {
+				if p, ok := points[res.ID()]; !ok || p != res.Location() {
+					t.Errorf("result %s (id %d) is not in the tree at %v", res.Data(), res.ID(), res.Location())
+				}
+			}
+		}
+		count, err := rtd.Count(tree.NewRectFromBounds(-9, 109, -6, 112))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if count != len(points) {
+			t.Errorf("count %d, want %d", count, len(points))
+		}
+	}
+
+	// strategy tidak disimpan, tree R* bisa dilanjutkan dengan insert Guttman
+	rtd = reopenAndInsert(t, rtd, dir, index.Options{Strategy: index.StrategyGuttman}, 500, func() tree.SpatialData {
+		p := tree.NewPoint(-7.79+faker.Float64Range(-0.01, 0.01), 110.37+faker.Float64Range(-0.01, 0.01))
+		return tree.NewSpatialData(p, []byte("extra"))
+	}, func(id uint64, obj tree.SpatialData) { points[id] = obj.Location() }, check)
+	if err := rtd.Close(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// reopenAndInsert. cek rtd, tutup, buka ulang dir dengan opts (misalnya strategy lain), insert n object dari newObj
+// & cek lagi. inserted dipanggil dengan id tiap object baru. return tree yang dibuka ulang, caller yang menutupnya.
+func reopenAndInsert(t *testing.T, rtd *index.Rtreed, dir string, opts index.Options, n int,
+	newObj func() tree.SpatialData, inserted func(id uint64, obj tree.SpatialData),
+	check func(rtd *index.Rtreed)) *index.Rtreed {
+	t.Helper()
+	check(rtd)
+	if err := rtd.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	rtd, err := index.Open(dir, opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i := 0; i < n; i++ {
+		obj := newObj()
+		id, err := rtd.InsertID(obj)
+		if err != nil {
+			t.Fatal(err)
+		}
+		inserted(id, obj)
+	}
+	check(rtd)
+	return rtd
+}
+
+// leafOverlap. total luas overlap antar MBR leaf di page file tree yang sudah ditutup & belum pernah delete (jadi
+// semua page leaf masih dipakai).
+func leafOverlap(t *testing.T, pageFile string, pageSize int) float64 {
+	t.Helper()
+	buf, err := os.ReadFile(pageFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var leaves []tree.Rect
+	// page 0 meta page
+	for off := pageSize; off+pageSize <= len(buf); off += pageSize {
+		node := disk.NewPageFromByteSlice(buf[off : off+pageSize]).DeserializeNode()
+		if !node.IsLeaf() || node.GetEntriesSize() == 0 {
+			continue
+		}
+		mbr := node.GetEntry(0).GetRect()
+		for _, e := range node.GetEntries()[1:] {
+			mbr = tree.CreateRectangle(mbr, e.GetRect())
+		}
+		leaves = append(leaves, mbr)
+	}
+
+	overlap := 0.0
+	for i, a := range leaves {
+		for _, b := range leaves[i+1:] {
+			w := math.Min(a.Max(0), b.Max(0)) - math.Max(a.Min(0), b.Min(0))
+			h := math.Min(a.Max(1), b.Max(1)) - math.Max(a.Min(1), b.Min(1))
+			if w > 0 && h > 0 {
+				overlap += w * h
+			}
+		}
+	}
+	return overlap
+}
+
+var splitters = []struct {
+	name     string
+	splitter index.Splitter
+}{
+	{"linear", index.LinearSplit{}},
+	{"quadratic", index.QuadraticSplit{}},
+	{"rstar", index.RStarSplit{}},
+	{"angtan", index.AngTanSplit{}},
+	{"hilbert", index.HilbertSplit{}},
+}
+
+// halfSplit. splitter yang melanggar minEntries.
+type halfSplit struct{}
+
+func (halfSplit) Split(entries []*tree.Entry, minEntries int) ([]*tree.Entry, []*tree.Entry) {
+	return entries[:1], entries[1:]
+}
+
+func TestSplitters(t *testing.T) {
+	faker := gofakeit.New(0)
+	for _, s := range splitters {
+		// tiap entry masuk tepat satu group, tiap group minimal minEntries
+		for _, dims := range []int{2, 3} {
+			for round := 0; round < 50; round++ {
+				entries := make([]*tree.Entry, 11)
+				for i := range entries {
+					lower, upper := make([]float64, dims), make([]float64, dims)
+					for d := range lower {
+						lower[d] = faker.Float64Range(-10, 10)
+						upper[d] = lower[d] + faker.Float64Range(0, 3)
+					}
+					r := tree.NewRectFromPoints(tree.NewPointN(lower...), tree.NewPointN(upper...))
+					entries[i] = tree.NewEntry(r, 0, tree.SpatialData{})
+				}
+				one, two := s.splitter.Split(entries, 4)
+				seen := make(map[*tree.Entry]int)
+				for _, e := range append(append([]*tree.Entry{}, one...), two...) {
+					seen[e]++
+				}
+				if len(one) < 4 || len(two) < 4 || len(one)+len(two) != len(entries) || len(seen) != len(entries) {
+					t.Fatalf("%s %dD: groups of %d & %d covering %d of %d entries", s.name, dims, len(one), len(two),
+						len(seen), len(entries))
+				}
+			}
+		}
+
+		rtd, err := index.Open(t.TempDir(), index.Options{MinEntries: 4, MaxEntries: 10, MaxSpatialDataInBytes: 16,
+			Splitter: s.splitter})
+		if err != nil {
+			t.Fatal(err)
+		}
+		points := make([]tree.Point, 0, 3000)
+		for i := 0; i < 3000; i++ {
+			lat, _ := faker.LatitudeInRange(-8, -6)
+			lon, _ := faker.LongitudeInRange(106, 112)
+			points = append(points, tree.NewPoint(lat, lon))
+			if err := rtd.Insert(tree.NewSpatialData(points[i], []byte("p"))); err != nil {
+				t.Fatal(err)
+			}
+		}
+		q := tree.NewRectFromBounds(-7.5, 107, -6.8, 110)
+		want := 0
+		for _, p := range points {
+			if q.ContainRect(p.ToRect(0)) {
+				want++
+			}
+		}
+		results, err := rtd.Search(q, index.PredicateIntersects)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(results) != want || want == 0 {
+			t.Errorf("%s: got %d results, want %d", s.name, len(results), want)
+		}
+		if err := rtd.Close(); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	rtd, err := index.Open(t.TempDir(), index.Options{MinEntries: 2, MaxEntries: 4, Splitter: halfSplit{}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer rtd.Close()
+	for i := 0; i < 5; i++ {
+		err = rtd.Insert(tree.NewSpatialData(tree.NewPoint(float64(i), float64(i)), nil))
+	}
+	if !errors.Is(err, index.ErrInvalidSplit) {
+		t.Errorf("splitter breaking min entries: got %v, want ErrInvalidSplit", err)
+	}
+}
+
+// BenchmarkSplitters. ns/insert = biaya insert, ns/op = radius query di tree hasil splitter tsb.
+func BenchmarkSplitters(b *testing.B) {
+	for _, s := range splitters {
+		b.Run(s.name, func(b *testing.B) {
+			rtd, err := index.Open(b.TempDir(), index.Options{MinEntries: 20, MaxEntries: 50, MaxSpatialDataInBytes: 4,
+				Splitter: s.splitter})
+			if err != nil {
+				b.Fatal(err)
+			}
+			defer rtd.Close()
+
+			faker := gofakeit.New(1)
+			start := time.Now()
+			for i := 0; i < 20000; i++ {
+				lat, _ := faker.LatitudeInRange(-7.818711242232534, -7.767187043571421)
+				lon, _ := faker.LongitudeInRange(110.32382482774563, 110.42872530361015)
+				if err := rtd.Insert(tree.NewSpatialData(tree.NewPoint(lat, lon), []byte("coba"))); err != nil {
+					b.Fatal(err)
+				}
+			}
+			insertNs := float64(time.Since(start).Nanoseconds()) / 20000
+
+			b.ResetTimer()
+			for i := 0; i < b.N; i++ {
+				lat, _ := faker.LatitudeInRange(-7.818711242232534, -7.767187043571421)
+				lon, _ := faker.LongitudeInRange(110.32382482774563, 110.42872530361015)
+				if _, err := rtd.SearchWithinRadius(tree.NewPoint(lat, lon), 0.2); err != nil {
+					b.Fatal(err)
+				}
+			}
+			b.ReportMetric(insertNs, "ns/insert")
+		})
+	}
+}
+
+var bulkLoaders = []struct {
+	name string
+	load func(rtd *index.Rtreed, objs iter.Seq[tree.SpatialData]) error
+}{
+	{"str", (*index.Rtreed).BulkLoad},
+	{"hilbert", (*index.Rtreed).BulkLoadHilbert},
+}
+
+func TestBulkLoad(t *testing.T) {
+	faker := gofakeit.New(0)
+	for _, loader := range bulkLoaders {
+		for _, n := range []int{0, 1, 10, 11, 37, 20000} {
+			testBulkLoad(t, faker, loader.name, loader.load, n)
+		}
+	}
+
+	rtd, err := index.Open(t.TempDir(), index.Options{MinEntries: 4, MaxEntries: 10, Temporal: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer rtd.Close()
+	for _, loader := range bulkLoaders {
+		err = loader.load(rtd, slices.Values([]tree.SpatialData{tree.NewSpatialData(tree.NewPoint(-7, 110), nil)}))
+		if !errors.Is(err, index.ErrTimeRange) {
+			t.Errorf("%s: object without time range into a temporal tree: got %v, want ErrTimeRange", loader.name, err)
+		}
+	}
+}
+
+// testBulkLoad. bulk load n object, bandingkan query dengan brute force, lalu update & reopen tree nya. budget
+// memory 1MB biar BulkLoadHilbert menulis beberapa run file.
+func testBulkLoad(t *testing.T, faker *gofakeit.Faker, name string,
+	load func(rtd *index.Rtreed, objs iter.Seq[tree.SpatialData]) error, n int) {
+	dir := t.TempDir()
+	rtd, err := index.Open(dir, index.Options{MinEntries: 4, MaxEntries: 10, MaxSpatialDataInBytes: 16,
+		BulkLoadMemoryInMB: 1})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	objs := make([]tree.SpatialData, 0, n)
+	for i := 0; i < n; i++ {
+		lat, _ := faker.LatitudeInRange(-8, -6)
+		lon, _ := faker.LongitudeInRange(106, 112)
+		obj := tree.NewSpatialData(tree.NewPoint(lat, lon), []byte(fmt.Sprintf("p%d", i)))
+		if i%3 == 0 {
+			obj = tree.NewSpatialDataRect(tree.NewRectFromBounds(lat, lon, lat+0.01, lon+0.02), []byte(fmt.Sprintf("r%d", i)))
+		}
+		objs = append(objs, obj)
+	}
+	if err := load(rtd, slices.Values(objs)); err != nil {
+		t.Fatal(err)
+	}
+	files, err := os.ReadDir(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, f := range files {
+		if strings.Contains(f.Name(), ".run") {
+			t.Errorf("%s n=%d: run file %s left in the db dir", name, n, f.Name())
+		}
+	}
+
+	// id mengikuti urutan objs
+	live := make(map[uint64]tree.SpatialData, n)
+	for i, obj := range objs {
+		live[uint64(i+1)] = obj
+	}
+
+	check := func(rtd *index.Rtreed) {
+		t.Helper()
+		for id, obj := range live {
+			got, err := rtd.Get(id)
+			if err != nil {
+				t.Fatalf("%s n=%d Get(%d): %v", name, n, id, err)
+			}
+			if !bytes.Equal(got.Data(), obj.Data()) || got.Bounds() != obj.Bounds() {
+				t.Errorf("%s n=%d Get(%d) = %s, want %s", name, n, id, got.Data(), obj.Data())
+			}
+		}
+		for _, q := range []tree.Rect{tree.NewRectFromBounds(-7.5, 107, -6.8, 110), tree.NewRectFromBounds(-9, 105, -5, 113)} {
+			want := 0
+			for _, obj := range live {
+				if q.Overlaps(obj.Extent()) {
+					want++
+				}
+			}
+			results, err := rtd.Search(q, index.PredicateIntersects)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(results) != want {
+				t.Errorf("%s n=%d: got %d results, want %d", name, n, len(results), want)
+			}
+			for _, res := range results {
+				if obj, ok := live[res.ID()]; !ok || !bytes.Equal(obj.Data(), res.Data()) {
+					t.Errorf("%s n=%d: result %s (id %d) is not in the tree", name, n, res.Data(), res.ID())
+				}
+			}
+		}
+	}
+	check(rtd)
+
+	if err := load(rtd, slices.Values(objs)); n > 0 && !errors.Is(err, index.ErrNotEmpty) {
+		t.Errorf("%s n=%d: bulk load into a filled tree: got %v, want ErrNotEmpty", name, n, err)
+	}
+
+	// tree hasil bulk load bisa diupdate seperti biasa
+	for id := range live {
+		if id%4 == 0 {
+			if found, err := rtd.DeleteByID(id); err != nil || !found {
+				t.Fatalf("%s n=%d DeleteByID(%d): %v %v", name, n, id, found, err)
+			}
+			delete(live, id)
+		}
+	}
+	rtd = reopenAndInsert(t, rtd, dir, index.Options{}, 200, func() tree.SpatialData {
+		lat, _ := faker.LatitudeInRange(-8, -6)
+		lon, _ := faker.LongitudeInRange(106, 112)
+		return tree.NewSpatialData(tree.NewPoint(lat, lon), []byte("extra"))
+	}, func(id uint64, obj tree.SpatialData) {
+		if _, ok := live[id]; ok || id <= uint64(n) {
+			t.Fatalf("%s n=%d: insert after bulk load got id %d", name, n, id)
+		}
+		live[id] = obj
+	}, check)
+	if err := rtd.Close(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestHilbertRTree(t *testing.T) {
+	// object masuk berurutan sepanjang satu arah, hilbert value nya kebanyakan terus naik
+	faker := gofakeit.New(0)
+	points := make(map[uint64]tree.Point, 6000)
+	pageFileSize := make(map[index.Strategy]int64)
+	var hilbertDir string
+	for _, strategy := range []index.Strategy{index.StrategyGuttman, index.StrategyHilbert} {
+		dir := t.TempDir()
+		rtd, err := index.Open(dir, index.Options{MinEntries: 4, MaxEntries: 10, MaxSpatialDataInBytes: 16,
+			Strategy: strategy})
+		if err != nil {
+			t.Fatal(err)
+		}
+		for i := 0; i < 6000; i++ {
+			p := tree.NewPoint(-7.9+float64(i)*0.00005, 110.3+float64(i)*0.00005)
+			id, err := rtd.InsertID(tree.NewSpatialData(p, []byte(fmt.Sprintf("p%d", i))))
+			if err != nil {
+				t.Fatal(err)
+			}
+			points[id] = p
+		}
+		if err := rtd.Close(); err != nil {
+			t.Fatal(err)
+		}
+		info, err := os.Stat(filepath.Join(dir, "go_rtreed.page"))
+		if err != nil {
+			t.Fatal(err)
+		}
+		pageFileSize[strategy] = info.Size()
+		if strategy == index.StrategyHilbert {
+			hilbertDir = dir
+		}
+	}
+	if pageFileSize[index.StrategyHilbert]*4 > pageFileSize[index.StrategyGuttman]*3 {
+		t.Errorf("hilbert tree takes %d bytes, guttman %d, want under 75%%", pageFileSize[index.StrategyHilbert],
+			pageFileSize[index.StrategyGuttman])
+	}
+
+	rtd, err := index.Open(hilbertDir, index.Options{Strategy: index.StrategyHilbert})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i := 0; i < 3000; i++ {
+		lat, _ := faker.LatitudeInRange(-7.9, -7.6)
+		lon, _ := faker.LongitudeInRange(110.3, 110.6)
+		id, err := rtd.InsertID(tree.NewSpatialData(tree.NewPoint(lat, lon), []byte("acak")))
+		if err != nil {
+			t.Fatal(err)
+		}
+		points[id] = tree.NewPoint(lat, lon)
+	}
+	for id := range points {
+		if id%3 == 0 {
+			if found, err := rtd.DeleteByID(id); err != nil || !found {
+				t.Fatalf("DeleteByID(%d): %v %v", id, found, err)
+			}
+			delete(points, id)
+		}
+	}
+
+	check := func(rtd *index.Rtreed) {
+		t.Helper()
+		for _, q := range []tree.Rect{tree.NewRectFromBounds(-7.85, 110.35, -7.8, 110.4),
+			tree.NewRectFromBounds(-7.8, 110.3, -7.6, 110.45), tree.NewRectFromBounds(-8, 110, -7, 111)} {
+			want := 0
+			for _, p := range points {
+				if q.ContainRect(p.ToRect(0)) {
+					want++
+				}
+			}
+			results, err := rtd.Search(q, index.PredicateIntersects)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(results) != want || want == 0 {
+				t.Errorf("%v: got %d results, want %d", q, len(results), want)
+			}
+			for _, res := range results {
+				if p, ok := points[res.ID()]; !ok || p != res.Location() {
+					t.Errorf("result %s (id %d) is not in the tree at %v", res.Data(), res.ID(), res.Location())
+				}
+			}
+		}
+		for id, p := range points {
+			if id%7 != 0 {
+				continue
+			}
+			if obj, err := rtd.Get(id); err != nil || obj.Location() != p {
+				t.Errorf("Get(%d) = %v %v, want %v", id, obj.Location(), err, p)
+			}
+		}
+	}
+
+	// LHV dijaga semua strategy, tree Hilbert bisa dilanjutkan dengan insert Guttman & sebaliknya
+	inserted := func(id uint64, obj tree.SpatialData) { points[id] = obj.Location() }
+	rtd = reopenAndInsert(t, rtd, hilbertDir, index.Options{Strategy: index.StrategyGuttman}, 500, func() tree.SpatialData {
+		p := tree.NewPoint(-7.7+faker.Float64Range(-0.01, 0.01), 110.4+faker.Float64Range(-0.01, 0.01))
+		return tree.NewSpatialData(p, []byte("extra"))
+	}, inserted, check)
+	rtd = reopenAndInsert(t, rtd, hilbertDir, index.Options{Strategy: index.StrategyHilbert}, 500, func() tree.SpatialData {
+		p := tree.NewPoint(-7.65+faker.Float64Range(-0.01, 0.01), 110.5+faker.Float64Range(-0.01, 0.01))
+		return tree.NewSpatialData(p, []byte("extra"))
+	}, inserted, check)
+	if err := rtd.Close(); err != nil {
+		t.Fatal(err)
+	}
+}